@@ -10,10 +10,22 @@ func ParseFlags() {
 	flag.StringVar(&Infos.TargetAddr, "a", "", "单个目标URL，如 http://example.com")
 	flag.StringVar(&Infos.Ports, "p", "", "端口号")
 	flag.StringVar(&Infos.TargetFile, "f", "", "目标列表文件，每行一个URL")
-	flag.StringVar(&Infos.OutputFile, "o", "result.txt", "结果输出文件路径（默认 result.txt）")
+	flag.StringVar(&Infos.OutputFile, "o", "result.txt", "文本结果文件路径，追加写入，为空时不写（默认 result.txt）")
+	flag.StringVar(&Infos.JSONFile, "json", "", "同时输出 JSON Lines 结果到指定文件，每个目标一行 JSON，便于程序处理")
+	flag.StringVar(&Infos.CSVFile, "csv", "", "同时输出 CSV 报表到指定文件，每个目标一行")
+	flag.StringVar(&Infos.XLSXFile, "xlsx", "", "同时输出 XLSX 报表到指定文件，第二个工作表按指纹和端口统计")
+	flag.StringVar(&Infos.HTMLFile, "html", "", "扫描结束后生成单文件 HTML 报告，可排序筛选、按指纹分组")
+	flag.StringVar(&Infos.MergeKey, "merge", "", "结果文件中把这些字段都相同的结果合并为一条资产记录，扫描结束时写出，可选 host,ip,port,scheme,status,title,body_hash,favicon，on 为 host,title,body_hash,favicon，为空时不合并、逐条写入（默认不合并）")
+	flag.StringVar(&Infos.CDNMode, "cdn", CDNAll, "CDN 节点的扫描方式：all 全部扫描，skip 跳过 CDN 节点只扫描真实 IP，one 每个域名只扫描一个 CDN 节点（默认 all）")
+	flag.StringVar(&Infos.Columns, "columns", "", "CSV/XLSX 报表的列，逗号分隔，可选 url,final_url,host,ip,port,scheme,status,title,length,time,server,icon_url,icon_hash,body_hash,cdn,cdn_provider,cnames,cdn_ips,real_ips,finger,tags,generator,cert,jarm,endpoints（默认 url,status,title,length,server,finger,icon_hash,ip,port,cdn）")
 	flag.IntVar(&Infos.Threads, "t", 500, "并发线程数（默认 10）")
 	flag.IntVar(&Infos.Timeout, "T", 5, "请求超时时间，单位秒（默认 10）")
-	flag.StringVar(&Infos.FingerFile, "finger", Finger_file, "指纹规则文件路径（默认 fingers.json）")
+	flag.StringVar(&Infos.FingerFile, "finger", Finger_file, "指纹规则文件、目录或通配符（如 rules/*.yaml），与内置指纹合并，同名 CMS 覆盖内置规则")
+	flag.IntVar(&Infos.Reload, "reload", 10, "指纹文件变化检查间隔，单位秒，0 为关闭（任何时候都可以发送 SIGHUP 重新加载）")
+	flag.IntVar(&Infos.MaxProbes, "probes", 0, "每个目标最多发送的主动探测请求数，如 /nacos/、/actuator/health，默认 0 不发送，需要时显式开启（如 -probes 20）")
+	flag.BoolVar(&Infos.JARM, "jarm", false, "计算存活 https 端口的 JARM TLS 指纹，供 jarm 位置的规则匹配（每个端口 10 次握手）")
+	flag.StringVar(&Infos.SANScope, "san", "", "证书域名扩展：把证书 CN/SAN 中属于这些域名后缀（逗号分隔，如 example.com,corp.local）的域名作为新目标扫描")
+	flag.IntVar(&Infos.MaxJS, "js", 0, "每个目标最多下载的同源 JS/CSS 文件数，供 js 位置的规则匹配，0 为关闭（默认 0）")
 
 	flag.Usage = func() {
 		fmt.Println("用法:")
@@ -32,6 +44,12 @@ func ParseFlags() {
 		os.Exit(1)
 	}
 
+	if Infos.CDNMode != CDNAll && Infos.CDNMode != CDNSkip && Infos.CDNMode != CDNOne {
+		fmt.Println("[!] -cdn 只能是 all、skip 或 one")
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	if Infos.TargetAddr != "" && Infos.TargetFile != "" {
 		fmt.Println("[!] 参数冲突：-u 和 -f 不能同时使用")
 		flag.Usage()
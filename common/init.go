@@ -12,9 +12,19 @@ func Dfinger_init() {
 	fmt.Printf("    单个目标: %s\n", Infos.TargetAddr)
 	fmt.Printf("    目标文件: %s\n", Infos.TargetFile)
 	fmt.Printf("    输出文件: %s\n", Infos.OutputFile)
+	fmt.Printf("    JSON结果: %s\n", Infos.JSONFile)
+	fmt.Printf("    CSV 报表: %s\n", Infos.CSVFile)
+	fmt.Printf("    XLSX报表: %s\n", Infos.XLSXFile)
+	fmt.Printf("    HTML报告: %s\n", Infos.HTMLFile)
+	fmt.Printf("    资产合并: %s\n", Infos.MergeKey)
 	fmt.Printf("    并发数:   %d\n", Infos.Threads)
 	fmt.Printf("    超时:     %d 秒\n", Infos.Timeout)
-	fmt.Printf("    指纹库:   %s\n", Infos.FingerFile)
+	fmt.Printf("    指纹库:   内置 %s\n", Infos.FingerFile)
+	fmt.Printf("    主动探测: %d 个/目标\n", Infos.MaxProbes)
+	fmt.Printf("    JS/CSS:   %d 个/目标\n", Infos.MaxJS)
+	fmt.Printf("    JARM:     %v\n", Infos.JARM)
+	fmt.Printf("    证书扩展: %s\n", Infos.SANScope)
+	fmt.Printf("    CDN 节点: %s\n", Infos.CDNMode)
 
 	Parse()
 
@@ -5,10 +5,8 @@ import (
 )
 
 var (
-	Finger_file    = "resource/fingers.json"
-	Cdn_cname_file = "resource/cdn_cname.txt"
-	Cdn_ip_file    = "resource/cdn_ip.txt"
-	DefaultPorts   = "80,81,88,99,443,800,801,808,888,1000,1010,1080,1099,2375,2379,3000,3128,5000,5003,5555,6080,7001,7002,7070,7071,7080,7200,7777,7890,8000,8001,8008,8010,8011,8020,8028,8030,8042,8053,8069,8070,8080,8081,8083,8088,8090,8091,8096,8100,8118,8161,8180,8181,8200,8222,8244,8280,8360,8443,8484,8800,8848,8868,8880,8888,8899,8983,8989,9000,9001,9002,9008,9010,9043,9060,9080,9081,9088,9090,9091,9100,9200,9443,9800,9981,9988,9999,10000,10001,10250,12443,18000,18080,18088,19001,20000,20880"
+	Finger_file  = "" // 默认只使用内置指纹库
+	DefaultPorts = "80,81,88,99,443,800,801,808,888,1000,1010,1080,1099,2375,2379,3000,3128,5000,5003,5555,6080,7001,7002,7070,7071,7080,7200,7777,7890,8000,8001,8008,8010,8011,8020,8028,8030,8042,8053,8069,8070,8080,8081,8083,8088,8090,8091,8096,8100,8118,8161,8180,8181,8200,8222,8244,8280,8360,8443,8484,8800,8848,8868,8880,8888,8899,8983,8989,9000,9001,9002,9008,9010,9043,9060,9080,9081,9088,9090,9091,9100,9200,9443,9800,9981,9988,9999,10000,10001,10250,12443,18000,18080,18088,19001,20000,20880"
 
 	DnsServers = []string{
 		"8.8.8.8",         // Google DNS
@@ -22,14 +20,33 @@ var (
 
 var Resolver = DNS.NewDNSResolver(DnsServers)
 
+// -cdn 取值
+const (
+	CDNAll  = "all"  // 扫描全部 CDN 节点
+	CDNSkip = "skip" // 跳过 CDN 节点，只扫描真实 IP
+	CDNOne  = "one"  // 每个域名只扫描一个 CDN 节点
+)
+
 type Info struct {
 	TargetAddr string // -a 目标
 	TargetFile string // -f 批量目标文件
 	Ports      string // -p 端口
 	OutputFile string // -o 输出结果文件
+	JSONFile   string // -json JSON Lines 结果文件路径
+	CSVFile    string // -csv CSV 报表路径
+	XLSXFile   string // -xlsx XLSX 报表路径
+	Columns    string // -columns 报表列，逗号分隔
+	HTMLFile   string // -html HTML 报告路径
+	MergeKey   string // -merge 结果文件按这些字段合并为资产，为空时不合并
+	CDNMode    string // -cdn CDN 节点的扫描方式：all、skip、one
 	Threads    int    // -c 并发线程数
 	Timeout    int    // -t 超时时间（秒）
-	FingerFile string // -finger 指纹库文件路径
+	FingerFile string // -finger 指纹库文件、目录或通配符，与内置指纹合并
+	MaxProbes  int    // -probes 每个目标最多发送的主动探测请求数
+	Reload     int    // -reload 指纹文件变化检查间隔（秒），0 为关闭
+	MaxJS      int    // -js 每个目标最多下载的同源 JS/CSS 文件数，0 为关闭
+	JARM       bool   // -jarm 计算存活 https 端口的 JARM 指纹
+	SANScope   string // -san 证书域名扩展的范围（域名后缀），为空时关闭
 }
 
 var Infos Info
@@ -6,6 +6,7 @@ import (
 	"github.com/patrickmn/go-cache"
 	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"time"
 )
@@ -39,60 +40,116 @@ func NewDNSResolver(servers []string) *DNSResolver {
 	return &DNSResolver{Servers: servers}
 }
 
+// Result 域名解析结果
+type Result struct {
+	CNAMEs []string // CNAME 链，按解析顺序，不含域名本身
+	IPs    []net.IP // A/AAAA 记录
+}
+
 func (r *DNSResolver) LookupIP(domain string) ([]net.IP, error) {
+	result, err := r.Lookup(domain)
+	if err != nil {
+		return nil, err
+	}
+	return result.IPs, nil
+}
+
+// Lookup 解析域名的 CNAME 链和 A/AAAA 记录
+func (r *DNSResolver) Lookup(domain string) (*Result, error) {
 	//fmt.Println("[DEBUG] 进入 LookupIP", domain)
 	//defer fmt.Println("[DEBUG] 离开 LookupIP", domain)
 	if cached, found := dnsCache.Get(domain); found {
-		return cached.([]net.IP), nil
+		return cached.(*Result), nil
 	}
 
-	ips, err := r.lookupIPWithCustomDNS(domain)
+	result, err := r.lookupWithCustomDNS(domain)
 	if err == nil {
-		dnsCache.Set(domain, ips, cache.DefaultExpiration)
-		return ips, nil
+		dnsCache.Set(domain, result, cache.DefaultExpiration)
+		return result, nil
 	}
 
-	ips, err = net.LookupIP(domain)
+	// 系统解析只能拿到最终的规范名，CNAME 链只有一级
+	ips, err := net.LookupIP(domain)
 	if err == nil {
-		dnsCache.Set(domain, ips, cache.DefaultExpiration)
-		return ips, nil
+		result = &Result{IPs: ips}
+		if cname, err := net.LookupCNAME(domain); err == nil {
+			if cname = normalizeName(cname); cname != "" && cname != normalizeName(domain) {
+				result.CNAMEs = []string{cname}
+			}
+		}
+		dnsCache.Set(domain, result, cache.DefaultExpiration)
+		return result, nil
 	}
 
 	return nil, fmt.Errorf("DNS解析失败: %v", err)
 }
 
-func (r *DNSResolver) lookupIPWithCustomDNS(domain string) ([]net.IP, error) {
+// lookupWithCustomDNS 向自定义 DNS 服务器分别查询 A 和 AAAA 记录，递归解析器会在应答中带上完整的 CNAME 链
+func (r *DNSResolver) lookupWithCustomDNS(domain string) (*Result, error) {
 	server := r.getRandomServer()
-
 	client := new(dns.Client)
-	message := new(dns.Msg)
-	message.SetQuestion(domain+".", dns.TypeANY)
-	message.RecursionDesired = true
-
-	start := time.Now()
-	resp, _, err := client.Exchange(message, server+":53")
-	elapsed := time.Since(start)
-
-	if err != nil {
-		return nil, fmt.Errorf("DNS 查询失败 (%s): %v (耗时: %v)", server, err, elapsed)
-	}
 
+	targets := make(map[string]string) // CNAME 记录：名称 -> 目标
 	var ips []net.IP
-	for _, ans := range resp.Answer {
-		switch t := ans.(type) {
-		case *dns.A:
-			ips = append(ips, t.A)
-		case *dns.AAAA:
-			ips = append(ips, t.AAAA)
+	var lastErr error
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		message := new(dns.Msg)
+		message.SetQuestion(dns.Fqdn(domain), qtype)
+		message.RecursionDesired = true
+
+		start := time.Now()
+		resp, _, err := client.Exchange(message, server+":53")
+		elapsed := time.Since(start)
+		if err != nil {
+			lastErr = fmt.Errorf("DNS 查询失败 (%s): %v (耗时: %v)", server, err, elapsed)
+			continue
+		}
+
+		for _, ans := range resp.Answer {
+			switch t := ans.(type) {
+			case *dns.A:
+				ips = append(ips, t.A)
+			case *dns.AAAA:
+				ips = append(ips, t.AAAA)
+			case *dns.CNAME:
+				targets[normalizeName(t.Hdr.Name)] = normalizeName(t.Target)
+			}
 		}
 	}
 
 	if len(ips) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
 		return nil, fmt.Errorf("没有解析到有效的 IP 地址")
 	}
 
-	dnsCache.Set(domain, ips, cache.DefaultExpiration)
-	return ips, nil
+	return &Result{CNAMEs: cnameChain(domain, targets), IPs: ips}, nil
+}
+
+// maxCNAMEChain CNAME 链最多跟随的跳数
+const maxCNAMEChain = 16
+
+// cnameChain 从域名开始沿 CNAME 记录排出完整的链，防止记录成环
+func cnameChain(domain string, targets map[string]string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	name := normalizeName(domain)
+	for len(chain) < maxCNAMEChain {
+		seen[name] = true
+		next, ok := targets[name]
+		if !ok || seen[next] {
+			break
+		}
+		chain = append(chain, next)
+		name = next
+	}
+	return chain
+}
+
+// normalizeName 域名转小写并去掉末尾的点
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
 }
 
 func (r *DNSResolver) getRandomServer() string {
@@ -0,0 +1,82 @@
+package DNS
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCnameChain(t *testing.T) {
+	// 超长的链：c0 -> c1 -> ... -> c20
+	long := map[string]string{}
+	var longChain []string
+	for i := 0; i < 20; i++ {
+		long[fmt.Sprintf("c%d.example.com", i)] = fmt.Sprintf("c%d.example.com", i+1)
+		longChain = append(longChain, fmt.Sprintf("c%d.example.com", i+1))
+	}
+
+	tests := []struct {
+		name    string
+		domain  string
+		targets map[string]string
+		want    []string
+	}{
+		{
+			name:    "没有 CNAME",
+			domain:  "www.example.com",
+			targets: map[string]string{},
+			want:    nil,
+		},
+		{
+			name:   "多级 CNAME",
+			domain: "www.example.com",
+			targets: map[string]string{
+				"www.example.com":               "www.example.com.cdn.dnsv1.com",
+				"www.example.com.cdn.dnsv1.com": "abc.cdntip.com",
+			},
+			want: []string{"www.example.com.cdn.dnsv1.com", "abc.cdntip.com"},
+		},
+		{
+			name:    "域名大小写和末尾的点",
+			domain:  "WWW.Example.COM.",
+			targets: map[string]string{"www.example.com": "edge.example.net"},
+			want:    []string{"edge.example.net"},
+		},
+		{
+			name:    "两个域名互指",
+			domain:  "a.example.com",
+			targets: map[string]string{"a.example.com": "b.example.com", "b.example.com": "a.example.com"},
+			want:    []string{"b.example.com"},
+		},
+		{
+			name:   "环在链的中间",
+			domain: "a.example.com",
+			targets: map[string]string{
+				"a.example.com": "b.example.com",
+				"b.example.com": "c.example.com",
+				"c.example.com": "d.example.com",
+				"d.example.com": "b.example.com",
+			},
+			want: []string{"b.example.com", "c.example.com", "d.example.com"},
+		},
+		{
+			name:    "指向自身",
+			domain:  "a.example.com",
+			targets: map[string]string{"a.example.com": "a.example.com"},
+			want:    nil,
+		},
+		{
+			name:    "超过长度上限时截断",
+			domain:  "c0.example.com",
+			targets: long,
+			want:    longChain[:maxCNAMEChain],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cnameChain(tt.domain, tt.targets); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("cnameChain(%q) = %v，期望 %v", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package finger
+
+// acMatcher Aho-Corasick 多模式匹配自动机
+// 加载规则时把同一位置上的所有 match 关键字编入一个自动机，
+// 检测时每个位置只需扫描一遍，得到命中的关键字集合
+type acMatcher struct {
+	root [256]int32 // 根节点的稠密转移表，减少最常见状态下的查找
+
+	// 非根节点的边按节点连续存放：edgeBytes[edgeStart[n]:edgeStart[n+1]]
+	edgeStart []int32
+	edgeBytes []byte
+	edgeNext  []int32
+
+	fail []int32 // 失败指针
+	term []int32 // 以该节点结尾的模式编号，-1 表示无
+	dict []int32 // 沿失败指针最近的终止节点，0 表示无
+
+	patterns []string
+	index    map[string]int32
+}
+
+func newACMatcher() *acMatcher {
+	return &acMatcher{index: make(map[string]int32)}
+}
+
+// add 加入模式串并返回编号，重复模式返回相同编号
+func (m *acMatcher) add(pattern string) int32 {
+	if id, ok := m.index[pattern]; ok {
+		return id
+	}
+	id := int32(len(m.patterns))
+	m.patterns = append(m.patterns, pattern)
+	m.index[pattern] = id
+	return id
+}
+
+// Len 返回模式数量
+func (m *acMatcher) Len() int {
+	return len(m.patterns)
+}
+
+// build 构建 trie 与失败指针，之后转换为紧凑的边数组
+func (m *acMatcher) build() {
+	children := []map[byte]int32{{}}
+	term := []int32{-1}
+
+	for id, p := range m.patterns {
+		node := int32(0)
+		for i := 0; i < len(p); i++ {
+			next, ok := children[node][p[i]]
+			if !ok {
+				next = int32(len(children))
+				children = append(children, map[byte]int32{})
+				term = append(term, -1)
+				children[node][p[i]] = next
+			}
+			node = next
+		}
+		term[node] = int32(id)
+	}
+
+	n := len(children)
+	fail := make([]int32, n)
+	dict := make([]int32, n)
+
+	// BFS 计算失败指针和输出链接
+	queue := make([]int32, 0, n)
+	for _, child := range children[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for b, child := range children[node] {
+			f := fail[node]
+			for {
+				if next, ok := children[f][b]; ok && next != child {
+					fail[child] = next
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = fail[f]
+			}
+			if term[fail[child]] >= 0 {
+				dict[child] = fail[child]
+			} else {
+				dict[child] = dict[fail[child]]
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	m.fail = fail
+	m.term = term
+	m.dict = dict
+	m.edgeStart = make([]int32, n+1)
+	m.edgeBytes = m.edgeBytes[:0]
+	m.edgeNext = m.edgeNext[:0]
+	for node := 0; node < n; node++ {
+		m.edgeStart[node] = int32(len(m.edgeBytes))
+		for b, child := range children[node] {
+			m.edgeBytes = append(m.edgeBytes, b)
+			m.edgeNext = append(m.edgeNext, child)
+		}
+	}
+	m.edgeStart[n] = int32(len(m.edgeBytes))
+
+	for i := range m.root {
+		m.root[i] = 0
+	}
+	for b, child := range children[0] {
+		m.root[b] = child
+	}
+}
+
+// next 计算状态转移
+func (m *acMatcher) next(state int32, b byte) int32 {
+	for state != 0 {
+		for e := m.edgeStart[state]; e < m.edgeStart[state+1]; e++ {
+			if m.edgeBytes[e] == b {
+				return m.edgeNext[e]
+			}
+		}
+		state = m.fail[state]
+	}
+	return m.root[b]
+}
+
+// scan 扫描文本，把命中的模式编号写入位图
+func (m *acMatcher) scan(text string) []uint64 {
+	hits := make([]uint64, (len(m.patterns)+63)/64)
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		state = m.next(state, text[i])
+		for n := state; n != 0; n = m.dict[n] {
+			if id := m.term[n]; id >= 0 {
+				hits[id>>6] |= 1 << (uint(id) & 63)
+			}
+		}
+	}
+	return hits
+}
+
+// hasHit 判断位图中是否包含该模式
+func hasHit(hits []uint64, id int32) bool {
+	return hits[id>>6]&(1<<(uint(id)&63)) != 0
+}
@@ -17,64 +17,92 @@ import (
 	"time"
 )
 
-func AnalyzeResponse(resp *http.Response, body string, req *http.Request, client *http.Client, urlInfo common.UrlInfo) (string, string, string, int, []DetectionResult) {
-	var (
-		title         string
-		iconURL       string
-		iconHash      string
-		contentLength int
-		fingers       []DetectionResult
-	)
-
-	title = ExtractTitle(strings.ReplaceAll(strconv.Itoa(resp.StatusCode), "206", "200"), resp.Header.Get("Content-Type"), body, resp.Header, 40)
-	iconURL, iconHash, _ = GetFaviconHash(req, client, body, req.URL)
+func AnalyzeResponse(resp *http.Response, body string, req *http.Request, client *http.Client, urlInfo common.UrlInfo) *ScanResult {
+	result := &ScanResult{
+		URL:        urlInfo.Scheme + "://" + urlInfo.Host + ":" + urlInfo.Port + urlInfo.Path,
+		FinalURL:   finalURL(resp, req),
+		Host:       urlInfo.Host,
+		Scheme:     urlInfo.Scheme,
+		StatusCode: resp.StatusCode,
+		Server:     resp.Header.Get("Server"),
+		Headers:    resp.Header,
+		Fingers:    []DetectionResult{},
+		Time:       time.Now(),
+	}
+	result.Port, _ = strconv.Atoi(urlInfo.Port)
+
+	input := newResponseData(resp, body, network.RawBody(resp), "", urlInfo.Path)
+	result.Title = input.Title
+	result.BodyHash = Mmh3Hash32(input.Raw)
+	result.Meta = input.Meta
+	result.Cert = input.Cert
+	result.JARM = network.LookupJARM(urlInfo.Host, urlInfo.Port)
+	input.JARM = result.JARM
+	if path, icon, err := getFavicon(req, client, body, req.URL); err == nil {
+		result.IconURL, result.IconHash, result.Icon = path, Mmh3Hash32(StandBase64(icon)), icon
+	}
+	input.FaviconHash = result.IconHash
 
 	// 优化 Content-Length 处理，若有指定优先使用指定的值
 	if contentLen := resp.Header.Get("Content-Length"); contentLen != "" {
-		contentLength, _ = strconv.Atoi(contentLen)
+		result.ContentLength, _ = strconv.Atoi(contentLen)
 	} else {
-		contentLength = len(body)
+		result.ContentLength = len(input.Raw)
 	}
 
-	detector := NewDetector(Rules)
-	fingers = detector.Detect(resp, []byte(body), title, iconHash, urlInfo.Path)
+	// 同一目标的首页规则与主动探测规则使用同一份规则集
+	rules := Detector.Rules()
+	if rules.uses(locJS) {
+		input.JS = FetchAssets(req, client, body)
+	}
+	result.Fingers = append(result.Fingers, rules.Detect(input)...)
+	result.Fingers = append(result.Fingers, RunProbes(rules, req, client, input)...)
+
+	// 内置登录页面识别
+	if result.Login = AnalyzeLogin(input); result.Login != nil {
+		result.Fingers = append(result.Fingers, DetectionResult{
+			CMS:     LoginCMS,
+			Level:   LoginLevel,
+			Tags:    []string{LoginTag},
+			Matched: result.Login.Evidence(),
+		})
+	}
 
-	return title, iconURL, iconHash, contentLength, fingers
+	return result
 }
 
 func ExtractTitle(code string, ctype string, body string, headers http.Header, TitleLen int) string {
+	var meta PageMeta
+	if isHTML(ctype, body) {
+		meta = ExtractMeta(body)
+	}
+	return pageTitle(code, ctype, body, headers, meta, TitleLen)
+}
+
+// pageTitle 根据状态码、类型和已解析的页面元信息生成标题
+func pageTitle(code string, ctype string, body string, headers http.Header, meta PageMeta, TitleLen int) string {
 
 	// 如果是 3xx 重定向状态码，返回 Location 头部的 URL
 	if strings.HasPrefix(code, "3") {
 		return fmt.Sprintf("%s-> %s", code, headers.Get("Location"))
 	}
 
-	// 如果 Content-Type 是 JSON、纯文本或空，处理响应体并返回标题
-	if strings.Contains(ctype, "json") || strings.Contains(ctype, "plain") || ctype == "" {
-		title := ReplaceStrings(body, " ", "\n", "[", "]")
-
-		// 如果标题长度超出最大长度，截断并加上 "..."
-		if len(title) > TitleLen {
-			return title[:TitleLen] + "..."
+	// HTML 页面依次使用 <title>、og:title、第一个 <h1>
+	if isHTML(ctype, body) {
+		for _, title := range []string{meta.Title, meta.OGTitle, meta.H1} {
+			if title != "" {
+				return truncateRunes(title, TitleLen)
+			}
 		}
-
-		return title
+		return "Unknown Title"
 	}
 
-	// 如果 Content-Type 是 HTML，提取 <title> 标签中的内容
-	if strings.Contains(ctype, "html") {
-		// 提取 <title> 标签内容
-		start := strings.Index(body, "<title>")
-		end := strings.Index(body, "</title>")
-		if start != -1 && end != -1 && start < end {
-			title := strings.TrimSpace(body[start+len("<title>") : end])
+	// 如果 Content-Type 是 JSON、纯文本或空，处理响应体并返回标题
+	if strings.Contains(ctype, "json") || strings.Contains(ctype, "plain") || ctype == "" {
+		title := ReplaceStrings(body, " ", "\n", "[", "]")
 
-			// 如果标题长度超出最大长度，截断并加上 "..."
-			if len(title) > TitleLen {
-				return title[:TitleLen] + "..."
-			}
-			return title
-		}
+		// 如果标题长度超出最大长度，按字符截断并加上 "..."
+		return truncateRunes(title, TitleLen)
 	}
 	return "Unknown Title"
 }
@@ -91,18 +119,27 @@ func ReplaceStrings(input string, replacements ...string) string {
 
 // 提取 favicon URL 和 hash（传入 body 为 string，baseURL 为 *url.URL）
 func GetFaviconHash(req *http.Request, client *http.Client, body string, baseURL *url.URL) (path string, iconHash string, err error) {
+	path, iconData, err := getFavicon(req, client, body, baseURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := Mmh3Hash32(StandBase64(iconData))
+	return path, hash, nil
+}
+
+// getFavicon 查找并下载 favicon，返回路径和图标内容
+func getFavicon(req *http.Request, client *http.Client, body string, baseURL *url.URL) (string, []byte, error) {
 	// 多模式查找 favicon URL
 	favURL, path, err := findFaviconURL(baseURL, body)
 	if err != nil {
-		return "", "", fmt.Errorf("parse favicon URL failed: %w", err)
+		return "", nil, fmt.Errorf("parse favicon URL failed: %w", err)
 	}
 	iconData, err := fetchFavicon(client, req, favURL)
 	if err != nil {
-		return "", "", fmt.Errorf("fetch favicon failed: %w", err)
+		return "", nil, fmt.Errorf("fetch favicon failed: %w", err)
 	}
-
-	hash := Mmh3Hash32(StandBase64(iconData))
-	return path, hash, nil
+	return path, iconData, nil
 }
 
 // 查找 favicon URL 的独立函数
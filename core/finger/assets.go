@@ -0,0 +1,227 @@
+package finger
+
+import (
+	"dfinger/common"
+	"dfinger/core/network"
+	"github.com/patrickmn/go-cache"
+	"github.com/projectdiscovery/gologger"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 静态资源的大小和缓存限制，避免超大或恶意构造的打包文件在大范围扫描时耗尽内存
+const (
+	maxAssetSize       = 1 << 20   // 单个资源最多读取的字节数，超出部分丢弃
+	maxCachedAsset     = 256 << 10 // 超过该大小的资源不缓存
+	maxAssetsPerSite   = 32        // 每个站点最多缓存的资源数
+	maxAssetCacheBytes = 64 << 20  // 缓存的资源总大小上限
+)
+
+// 静态资源内容缓存，按 协议://主机:端口 + 路径 缓存，同一站点共用的资源只下载一次，失败也缓存为空
+var assetCache = newAssetStore(5*time.Minute, 10*time.Minute)
+
+// assetStore 带容量限制的静态资源缓存，记录每个站点的资源数和总大小，过期删除时扣减
+type assetStore struct {
+	cache *cache.Cache
+	mu    sync.Mutex
+	sites map[string]int // 站点 -> 已缓存的资源数
+	bytes int            // 已缓存的总字节数
+}
+
+// cachedAsset 缓存中的资源内容
+type cachedAsset struct {
+	site    string
+	content string
+}
+
+func newAssetStore(expiration, cleanup time.Duration) *assetStore {
+	s := &assetStore{cache: cache.New(expiration, cleanup), sites: make(map[string]int)}
+	s.cache.OnEvicted(func(_ string, v interface{}) {
+		a := v.(cachedAsset)
+		s.release(a.site, len(a.content))
+	})
+	return s
+}
+
+func (s *assetStore) get(site, path string) (string, bool) {
+	v, found := s.cache.Get(site + path)
+	if !found {
+		return "", false
+	}
+	return v.(cachedAsset).content, true
+}
+
+// put 缓存资源，超过单个大小、站点资源数或总大小上限时不缓存
+func (s *assetStore) put(site, path, content string) bool {
+	if len(content) > maxCachedAsset {
+		return false
+	}
+	s.mu.Lock()
+	if s.sites[site] >= maxAssetsPerSite || s.bytes+len(content) > maxAssetCacheBytes {
+		s.mu.Unlock()
+		return false
+	}
+	s.sites[site]++
+	s.bytes += len(content)
+	s.mu.Unlock()
+
+	// 并发下载同一资源时只保留先写入的一份
+	if err := s.cache.Add(site+path, cachedAsset{site: site, content: content}, cache.DefaultExpiration); err != nil {
+		s.release(site, len(content))
+		return false
+	}
+	return true
+}
+
+func (s *assetStore) release(site string, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sites[site]--; s.sites[site] <= 0 {
+		delete(s.sites, site)
+	}
+	s.bytes -= size
+}
+
+// FetchAssets 下载页面引用的同源 JS/CSS 文件，最多 common.Infos.MaxJS 个，返回拼接后的内容
+func FetchAssets(baseReq *http.Request, client *http.Client, body string) string {
+	limit := common.Infos.MaxJS
+	if limit <= 0 {
+		return ""
+	}
+
+	var contents []string
+	for _, path := range extractAssets(body, baseReq) {
+		if limit == 0 {
+			break
+		}
+		limit--
+
+		site := siteKey(baseReq)
+		content, found := assetCache.get(site, path)
+		if !found {
+			content = fetchAsset(baseReq, client, path)
+			assetCache.put(site, path, content)
+		}
+		if content != "" {
+			contents = append(contents, content)
+		}
+	}
+	return strings.Join(contents, "\n")
+}
+
+// siteKey 站点标识，域名目标使用 Host 头，多个解析 IP 共用同一份缓存
+func siteKey(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	port := req.URL.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[req.URL.Scheme]
+	}
+	return req.URL.Scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// extractAssets 提取 <script src> 和 <link rel="stylesheet" href> 中的同源地址，返回去重后的路径（含查询参数）
+func extractAssets(body string, baseReq *http.Request) []string {
+	base := baseReq.URL
+	hosts := map[string]bool{strings.ToLower(base.Hostname()): true}
+	if baseReq.Host != "" {
+		h := baseReq.Host
+		if hh, _, err := net.SplitHostPort(h); err == nil {
+			h = hh
+		}
+		hosts[strings.ToLower(h)] = true
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" || strings.HasPrefix(ref, "data:") {
+			return
+		}
+		u, err := base.Parse(ref)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !hosts[strings.ToLower(u.Hostname())] {
+			return
+		}
+		// 绝对地址比较主机名和端口，不比较协议，请求仍然发往当前目标
+		if !sameSitePort(u.Port(), base.Port()) {
+			return
+		}
+		path := u.RequestURI()
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return paths
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		if !hasAttr {
+			continue
+		}
+		switch atom.Lookup(name) {
+		case atom.Script:
+			add(tagAttrs(z)["src"])
+		case atom.Link:
+			if attrs := tagAttrs(z); hasToken(attrs["rel"], "stylesheet") {
+				add(attrs["href"])
+			}
+		}
+	}
+}
+
+// sameSitePort 判断资源地址的端口与目标是否一致，未写端口和 80、443 都视为默认端口
+func sameSitePort(port, base string) bool {
+	isDefault := func(p string) bool { return p == "" || p == "80" || p == "443" }
+	if isDefault(base) {
+		return isDefault(port)
+	}
+	return port == base
+}
+
+// fetchAsset 下载单个静态资源，最多读取 maxAssetSize 字节，失败或非 200 返回空字符串
+func fetchAsset(baseReq *http.Request, client *http.Client, path string) string {
+	target, err := baseReq.URL.Parse(path)
+	if err != nil {
+		return ""
+	}
+	req := cloneRequest(baseReq)
+	req.URL = target
+	req.Method = http.MethodGet
+
+	resp, err := client.Do(req)
+	if err != nil {
+		gologger.Debug().Msgf("下载静态资源失败 %s: %v", target, err)
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize))
+	if err != nil && len(raw) == 0 {
+		gologger.Debug().Msgf("读取静态资源失败 %s: %v", target, err)
+		return ""
+	}
+	body, _ := network.DecodeBody(raw, resp.Header.Get("Content-Type"))
+	return body
+}
@@ -0,0 +1,177 @@
+package finger
+
+import (
+	"dfinger/common"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExtractAssets(t *testing.T) {
+	body := `<html><head>
+		<script src="/static/app.js"></script>
+		<script src="static/rel.js?v=1"></script>
+		<script src="/static/app.js"></script>
+		<script src="http://www.example.com/abs.js"></script>
+		<script src="https://www.example.com/other-scheme.js"></script>
+		<script src="http://WWW.EXAMPLE.COM/upper.js"></script>
+		<script src="http://www.example.com:8080/other-port.js"></script>
+		<script src="http://10.0.0.1/by-ip.js"></script>
+		<script src="//cdn.example.net/jquery.js"></script>
+		<script src="https://cdn.example.net/vue.js"></script>
+		<script src="data:text/javascript,alert(1)"></script>
+		<script src="javascript:void(0)"></script>
+		<script>inline()</script>
+		<SCRIPT SRC="/static/upper-tag.js"></SCRIPT>
+		<link rel="stylesheet" href="/css/site.css">
+		<link rel="alternate stylesheet" href="/css/alt.css">
+		<link rel="icon" href="/favicon.ico">
+		<link rel="preload" href="/static/preload.js">
+		</head></html>`
+
+	// 按 IP 访问域名目标：IP 和 Host 头中的域名都视为同源
+	req, _ := http.NewRequest(http.MethodGet, "http://10.0.0.1/app/index.html", nil)
+	req.Host = "www.example.com"
+	want := []string{
+		"/static/app.js",
+		"/app/static/rel.js?v=1",
+		"/abs.js",
+		"/other-scheme.js",
+		"/upper.js",
+		"/by-ip.js",
+		"/static/upper-tag.js",
+		"/css/site.css",
+		"/css/alt.css",
+	}
+	if got := extractAssets(body, req); !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractAssets = %q\n期望 %q", got, want)
+	}
+
+	// 非默认端口的目标，相同端口的绝对地址才算同源
+	req, _ = http.NewRequest(http.MethodGet, "http://www.example.com:8080/", nil)
+	got := extractAssets(body, req)
+	if !reflect.DeepEqual(got[:2], []string{"/static/app.js", "/static/rel.js?v=1"}) || !contains(got, "/other-port.js") || contains(got, "/abs.js") {
+		t.Fatalf("非默认端口 extractAssets = %q", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// assetServer 记录每个路径的请求次数
+type assetServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func newAssetServer(t *testing.T, handler http.HandlerFunc) *assetServer {
+	s := &assetServer{hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.RequestURI()]++
+		s.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *assetServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+func setMaxJS(t *testing.T, n int) {
+	old := common.Infos.MaxJS
+	common.Infos.MaxJS = n
+	t.Cleanup(func() { common.Infos.MaxJS = old })
+}
+
+func TestFetchAssets(t *testing.T) {
+	big := "var big='" + strings.Repeat("x", maxAssetSize) + "';"
+	srv := newAssetServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big.js":
+			fmt.Fprint(w, big)
+		case "/missing.js":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprintf(w, "/* %s */", r.URL.Path)
+		}
+	})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/", nil)
+	body := `<script src="/a.js"></script><script src="/missing.js"></script><script src="/big.js"></script><script src="/b.js"></script>`
+
+	setMaxJS(t, 0)
+	if got := FetchAssets(req, srv.Client(), body); got != "" || srv.count("/a.js") != 0 {
+		t.Fatal("-js 为 0 时不应下载")
+	}
+
+	// 每个目标最多下载 MaxJS 个资源
+	setMaxJS(t, 3)
+	got := FetchAssets(req, srv.Client(), body)
+	if srv.count("/b.js") != 0 {
+		t.Fatal("超出 -js 上限的资源不应下载")
+	}
+	if !strings.HasPrefix(got, "/* /a.js */\n") || strings.Contains(got, "Not Found") {
+		t.Fatalf("下载结果 %.40q", got)
+	}
+	if n := len(got) - len("/* /a.js */\n"); n != maxAssetSize {
+		t.Fatalf("超大资源应截断为 %d 字节，实际 %d", maxAssetSize, n)
+	}
+
+	// 小资源和失败结果缓存，超大资源不缓存
+	FetchAssets(req, srv.Client(), body)
+	if srv.count("/a.js") != 1 || srv.count("/missing.js") != 1 {
+		t.Fatalf("已缓存的资源不应重复下载: a.js %d 次，missing.js %d 次", srv.count("/a.js"), srv.count("/missing.js"))
+	}
+	if srv.count("/big.js") != 2 {
+		t.Fatalf("超大资源不应缓存，下载 %d 次", srv.count("/big.js"))
+	}
+}
+
+func TestAssetStoreLimits(t *testing.T) {
+	s := newAssetStore(0, 0)
+
+	if s.put("http://a:80", "/big.js", strings.Repeat("x", maxCachedAsset+1)) {
+		t.Fatal("超过大小上限的资源不应缓存")
+	}
+	for i := 0; i < maxAssetsPerSite; i++ {
+		if !s.put("http://a:80", fmt.Sprintf("/%d.js", i), "x") {
+			t.Fatalf("第 %d 个资源应缓存", i)
+		}
+	}
+	if s.put("http://a:80", "/more.js", "x") {
+		t.Fatal("超过站点资源数上限后不应缓存")
+	}
+	if !s.put("http://b:80", "/more.js", "x") {
+		t.Fatal("其他站点不受影响")
+	}
+	if s.put("http://b:80", "/more.js", "y") {
+		t.Fatal("已缓存的资源不应重复写入")
+	}
+	if content, ok := s.get("http://b:80", "/more.js"); !ok || content != "x" {
+		t.Fatalf("get = %q, %v", content, ok)
+	}
+
+	// 删除后扣减计数，可以继续缓存
+	s.cache.Delete("http://a:80/0.js")
+	if !s.put("http://a:80", "/more.js", "x") {
+		t.Fatal("删除后应可以继续缓存")
+	}
+	if s.bytes != maxAssetsPerSite+1 || s.sites["http://a:80"] != maxAssetsPerSite {
+		t.Fatalf("计数错误: bytes=%d sites=%v", s.bytes, s.sites)
+	}
+}
@@ -0,0 +1,100 @@
+package finger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CertInfo HTTPS 证书信息
+type CertInfo struct {
+	Subject    string    `json:"subject"`            // 使用者，如 CN=FortiGate,O=Fortinet
+	CommonName string    `json:"common_name"`        // 使用者的 CN
+	Issuer     string    `json:"issuer"`             // 颁发者
+	SANs       []string  `json:"sans,omitempty"`     // 备用名称，包括域名、IP 和邮箱
+	Serial     string    `json:"serial"`             // 序列号（十六进制）
+	NotBefore  time.Time `json:"not_before"`         // 生效时间
+	NotAfter   time.Time `json:"not_after"`          // 过期时间
+	SelfSigned bool      `json:"self_signed"`        // 自签名
+	Expired    bool      `json:"expired"`            // 已过期或尚未生效
+	Mismatch   bool      `json:"mismatch"`           // 与访问的域名不匹配，IP 目标不校验
+	Hostname   string    `json:"hostname,omitempty"` // 用于校验的主机名
+}
+
+// ParseCert 读取响应的叶子证书，非 HTTPS 响应返回 nil
+func ParseCert(resp *http.Response) *CertInfo {
+	if resp == nil || resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	cert := resp.TLS.PeerCertificates[0]
+
+	info := &CertInfo{
+		Subject:    cert.Subject.String(),
+		CommonName: cert.Subject.CommonName,
+		Issuer:     cert.Issuer.String(),
+		Serial:     fmt.Sprintf("%X", cert.SerialNumber),
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+	}
+	info.SANs = append(info.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	info.SANs = append(info.SANs, cert.EmailAddresses...)
+
+	now := time.Now()
+	info.Expired = now.After(cert.NotAfter) || now.Before(cert.NotBefore)
+	// CheckSignatureFrom 要求颁发者带 CA 标记，设备的自签名证书经常没有，这里直接用自身公钥验签
+	info.SelfSigned = bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+
+	if resp.Request != nil {
+		info.Hostname = requestHostname(resp.Request)
+		// 直接访问 IP 时证书几乎不会包含该 IP，只校验域名
+		info.Mismatch = info.Hostname != "" && net.ParseIP(info.Hostname) == nil && cert.VerifyHostname(info.Hostname) != nil
+	}
+	return info
+}
+
+// requestHostname 请求实际访问的主机名，域名目标取 Host 头
+func requestHostname(req *http.Request) string {
+	host := req.Host
+	if host == "" && req.URL != nil {
+		host = req.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// Name 证书的展示名称，使用 CN，没有 CN 时返回完整使用者
+func (c *CertInfo) Name() string {
+	if c.CommonName != "" {
+		return c.CommonName
+	}
+	return c.Subject
+}
+
+// Flags 证书问题标记
+func (c *CertInfo) Flags() []string {
+	var flags []string
+	if c.SelfSigned {
+		flags = append(flags, "自签名")
+	}
+	if c.Expired {
+		flags = append(flags, "已过期")
+	}
+	if c.Mismatch {
+		flags = append(flags, "域名不匹配")
+	}
+	return flags
+}
+
+// locationText cert 位置的匹配内容，每行一项
+func (c *CertInfo) locationText() string {
+	return "subject: " + c.Subject + "\nissuer: " + c.Issuer + "\nsan: " + strings.Join(c.SANs, ",") + "\nserial: " + c.Serial
+}
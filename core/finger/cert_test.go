@@ -0,0 +1,102 @@
+package finger
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// certResponse 返回带有自签名证书的 https 响应
+func certResponse(t *testing.T, subject pkix.Name, host string) *http.Response {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1f),
+		Subject:      subject,
+		DNSNames:     []string{"vpn.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Response{
+		TLS:     &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+		Request: &http.Request{Host: host, URL: &url.URL{Scheme: "https", Host: host}},
+	}
+}
+
+func TestParseCert(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  pkix.Name
+		host     string
+		wantCN   string
+		wantName string
+		mismatch bool
+	}{
+		{
+			name:     "普通 CN",
+			subject:  pkix.Name{CommonName: "vpn.example.com", Organization: []string{"Example"}},
+			host:     "vpn.example.com",
+			wantCN:   "vpn.example.com",
+			wantName: "vpn.example.com",
+		},
+		{
+			// 使用者字符串中逗号会被转义，不能按逗号拆分
+			name:     "CN 中带逗号",
+			subject:  pkix.Name{CommonName: "Acme, Inc. Gateway", Organization: []string{"Acme, Inc."}},
+			host:     "10.0.0.1:443",
+			wantCN:   "Acme, Inc. Gateway",
+			wantName: "Acme, Inc. Gateway",
+		},
+		{
+			name:     "CN 中带特殊字符",
+			subject:  pkix.Name{CommonName: "CN=fake+O=x"},
+			host:     "10.0.0.1",
+			wantCN:   "CN=fake+O=x",
+			wantName: "CN=fake+O=x",
+		},
+		{
+			name:     "没有 CN",
+			subject:  pkix.Name{Organization: []string{"Fortinet"}, OrganizationalUnit: []string{"FortiGate"}},
+			host:     "www.example.com",
+			wantName: "OU=FortiGate,O=Fortinet",
+			mismatch: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseCert(certResponse(t, tt.subject, tt.host))
+			if info == nil {
+				t.Fatal("ParseCert 返回 nil")
+			}
+			if info.CommonName != tt.wantCN || info.Name() != tt.wantName {
+				t.Errorf("CommonName = %q，Name = %q，期望 %q、%q", info.CommonName, info.Name(), tt.wantCN, tt.wantName)
+			}
+			if !info.SelfSigned || info.Expired || info.Mismatch != tt.mismatch || info.Serial != "1F" {
+				t.Errorf("证书信息错误: %+v", info)
+			}
+		})
+	}
+
+	if ParseCert(&http.Response{}) != nil {
+		t.Error("非 HTTPS 响应应返回 nil")
+	}
+}
@@ -0,0 +1,186 @@
+package finger
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/projectdiscovery/gologger"
+	"io"
+	"os"
+	"strings"
+)
+
+// RulesCommand 处理 rules 子命令，返回进程退出码
+func RulesCommand(args []string) int {
+	if len(args) == 0 {
+		rulesUsage()
+		return 2
+	}
+	switch args[0] {
+	case "convert":
+		return rulesConvert(args[1:])
+	case "lint":
+		return rulesLint(args[1:])
+	case "test":
+		return rulesTest(args[1:])
+	}
+	rulesUsage()
+	return 2
+}
+
+func rulesUsage() {
+	fmt.Println("用法:")
+	fmt.Println("  - 转换第三方指纹库: ./dfinger rules convert -o fingers.json finger.json technologies/*.json")
+	fmt.Println("  - 检查指纹库:       ./dfinger rules lint rules/  （不带参数时检查内置指纹库）")
+	fmt.Println("  - 测试指纹样本:     ./dfinger rules test -samples samples/ rules/")
+}
+
+// rulesTest 用规则自带的样本和样本目录测试规则，存在失败项时返回非零退出码
+func rulesTest(args []string) int {
+	fs := flag.NewFlagSet("rules test", flag.ExitOnError)
+	samples := fs.String("samples", "", "原始响应样本目录，结构为 <CMS>/positive/* 与 <CMS>/negative/*")
+	fs.Parse(args)
+
+	rules, err := LoadFingerprints(strings.Join(fs.Args(), ","))
+	if err != nil {
+		gologger.Error().Msgf("%v", err)
+		return 1
+	}
+	detector := NewDetector(rules)
+
+	tested, failures := TestRuleSamples(detector)
+	if *samples != "" {
+		n, dirFailures, err := TestSampleDir(detector, *samples)
+		if err != nil {
+			gologger.Error().Msgf("%v", err)
+			return 1
+		}
+		tested += n
+		failures = append(failures, dirFailures...)
+	}
+
+	for _, f := range failures {
+		gologger.Error().Msgf("%s", f)
+	}
+	gologger.Info().Msgf("测试样本 %d 个，失败 %d 个", tested, len(failures))
+	if len(failures) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// rulesLint 检查指纹库，参数为文件、目录或通配符，为空时检查内置指纹库，发现问题时返回非零退出码
+func rulesLint(args []string) int {
+	fs := flag.NewFlagSet("rules lint", flag.ExitOnError)
+	fs.Parse(args)
+
+	var files []RuleFile
+	if fs.NArg() == 0 {
+		rules, err := EmbeddedRules()
+		if err != nil {
+			gologger.Error().Msgf("%v", err)
+			return 1
+		}
+		files = append(files, RuleFile{Path: EmbeddedSource, Rules: rules})
+	}
+	for _, spec := range fs.Args() {
+		paths, err := ExpandRulePaths(spec)
+		if err != nil {
+			gologger.Error().Msgf("%v", err)
+			return 1
+		}
+		for _, path := range paths {
+			rules, err := ReadRules(path)
+			if err != nil {
+				gologger.Error().Msgf("%v", err)
+				return 1
+			}
+			files = append(files, RuleFile{Path: path, Rules: rules})
+		}
+	}
+
+	total := 0
+	for _, f := range files {
+		issues := LintRules(f.Rules, f.Path)
+		for _, issue := range issues {
+			gologger.Error().Msgf("%s", issue)
+		}
+		gologger.Info().Msgf("%s: %d 条规则，%d 个问题", f.Path, len(f.Rules), len(issues))
+		total += len(issues)
+	}
+	if len(files) > 1 {
+		issues := LintRuleFiles(files)
+		for _, issue := range issues {
+			gologger.Error().Msgf("%s", issue)
+		}
+		gologger.Info().Msgf("%d 个文件合并检查：%d 个跨文件问题", len(files), len(issues))
+		total += len(issues)
+	}
+	if total > 0 {
+		return 1
+	}
+	return 0
+}
+
+// rulesConvert 把 EHole / FingerprintHub / Wappalyzer 指纹库转换为 dfinger JSON
+func rulesConvert(args []string) int {
+	fs := flag.NewFlagSet("rules convert", flag.ExitOnError)
+	output := fs.String("o", "-", "输出文件，- 为标准输出")
+	format := fs.String("format", "", "输入格式 ehole/hub/wappalyzer/dfinger，为空时自动识别")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("[!] 至少指定一个输入文件")
+		fs.Usage()
+		return 2
+	}
+
+	var (
+		rules  []FingerprintRule
+		issues int
+	)
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			gologger.Error().Msgf("%v", err)
+			return 1
+		}
+		fps, problems, err := ParseRules(path, data, *format)
+		if err != nil {
+			gologger.Error().Msgf("%v", err)
+			return 1
+		}
+		for _, p := range problems {
+			gologger.Info().Msgf("无法转换 %s", p)
+		}
+		issues += len(problems)
+		rules = append(rules, fps...)
+	}
+
+	// 转换结果必须能通过编译，避免写出无法加载的指纹库
+	if _, err := CompileRules(rules, "convert"); err != nil {
+		gologger.Error().Msgf("转换结果编译失败: %v", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			gologger.Error().Msgf("无法写入输出文件: %s", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rules); err != nil {
+		gologger.Error().Msgf("写入失败: %v", err)
+		return 1
+	}
+
+	gologger.Info().Msgf("转换完成: %d 条规则，%d 个条件无法转换", len(rules), issues)
+	return 0
+}
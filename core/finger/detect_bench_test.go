@@ -0,0 +1,185 @@
+package finger
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// legacyDetect 旧版检测逻辑：每个关键字单独转小写、单独编译正则
+func legacyDetect(rules []FingerprintRule, resp *http.Response, body []byte, title string, faviconHash string, path string) []DetectionResult {
+	bodyStr := string(body)
+	var results []DetectionResult
+
+	for _, rule := range rules {
+		allMatched := rule.Logic == "and"
+		var matchedKeywords []string
+
+		for _, cond := range rule.Conditions {
+			var data string
+			switch cond.Location {
+			case "header":
+				data = joinHeaders(resp)
+			case "body":
+				data = bodyStr
+			case "title":
+				data = title
+			case "favicon":
+				data = faviconHash
+			case "path":
+				data = path
+			}
+
+			var matched []string
+			ok := true
+			for _, keyword := range cond.Keywords {
+				var hit bool
+				if cond.Matcher == "regex" {
+					hit, _ = regexp.MatchString("(?i)"+keyword, data)
+				} else {
+					hit = strings.Contains(strings.ToLower(data), strings.ToLower(keyword))
+				}
+				if !hit {
+					ok = false
+					break
+				}
+				matched = append(matched, keyword)
+			}
+			ok = ok && len(matched) > 0
+
+			if rule.Logic == "or" {
+				if ok {
+					allMatched = true
+					matchedKeywords = append(matchedKeywords, matched...)
+					break
+				}
+			} else {
+				if !ok {
+					allMatched = false
+					break
+				}
+				matchedKeywords = append(matchedKeywords, matched...)
+			}
+		}
+
+		if allMatched {
+			results = append(results, DetectionResult{
+				CMS:     rule.CMS,
+				Level:   rule.Level,
+				Tags:    rule.Tags,
+				Matched: matchedKeywords,
+			})
+		}
+	}
+	return results
+}
+
+// benchRules 生成 n 条规则，大部分为 body/header 的 match 规则，少量正则
+func benchRules(n int) []FingerprintRule {
+	r := rand.New(rand.NewSource(1))
+	rules := make([]FingerprintRule, 0, n)
+	for i := 0; i < n; i++ {
+		rule := FingerprintRule{
+			CMS:   fmt.Sprintf("cms-%d", i),
+			Level: 3,
+			Logic: "and",
+			Tags:  []string{fmt.Sprintf("tag-%d", i)},
+		}
+		if i%2 == 0 {
+			rule.Logic = "or"
+		}
+		for j := 0; j < 1+r.Intn(3); j++ {
+			cond := Condition{Location: "body", Matcher: "match"}
+			if r.Intn(4) == 0 {
+				cond.Location = "header"
+			}
+			for k := 0; k < 1+r.Intn(2); k++ {
+				cond.Keywords = append(cond.Keywords, fmt.Sprintf("Marker-%d-%d", r.Intn(n*2), k))
+			}
+			if i%50 == 0 {
+				cond.Matcher = "regex"
+				cond.Keywords = []string{fmt.Sprintf(`version-%d\.\d+`, r.Intn(n*2))}
+			}
+			rule.Conditions = append(rule.Conditions, cond)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// benchBody 生成约 size 字节的页面，其中散布部分规则关键字
+func benchBody(size int) []byte {
+	r := rand.New(rand.NewSource(2))
+	var sb strings.Builder
+	sb.WriteString("<html><head><title>bench</title></head><body>")
+	for sb.Len() < size {
+		fmt.Fprintf(&sb, "<div class=\"item-%d\">lorem ipsum dolor sit amet marker-%d-0 </div>\n", r.Intn(1000), r.Intn(10000))
+		if r.Intn(500) == 0 {
+			fmt.Fprintf(&sb, "<span>Version-%d.%d</span>\n", r.Intn(10000), r.Intn(10))
+		}
+	}
+	sb.WriteString("</body></html>")
+	return []byte(sb.String())
+}
+
+func benchResponse() *http.Response {
+	return &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Server":       {"nginx"},
+			"Content-Type": {"text/html"},
+			"X-Powered-By": {"Marker-42-0"},
+		},
+	}
+}
+
+func benchInput(resp *http.Response, body []byte) *ResponseData {
+	return &ResponseData{Resp: resp, Body: string(body), Title: "bench", Path: "/"}
+}
+
+func TestDetectMatchesLegacy(t *testing.T) {
+	rules := benchRules(5000)
+	rs, err := CompileRules(rules, "bench")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, body := benchResponse(), benchBody(64<<10)
+
+	got := NewDetector(rs).Detect(benchInput(resp, body))
+	want := legacyDetect(rules, resp, body, "bench", "", "/")
+	if len(want) == 0 {
+		t.Fatal("expected some legacy matches")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Detect returned %d results, legacy returned %d", len(got), len(want))
+	}
+}
+
+func BenchmarkDetectLegacy(b *testing.B) {
+	rules := benchRules(5000)
+	resp, body := benchResponse(), benchBody(1<<20)
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		legacyDetect(rules, resp, body, "bench", "", "/")
+	}
+}
+
+func BenchmarkDetect(b *testing.B) {
+	rs, err := CompileRules(benchRules(5000), "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	detector := NewDetector(rs)
+	resp, body := benchResponse(), benchBody(1<<20)
+	input := benchInput(resp, body)
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		detector.Detect(input)
+	}
+}
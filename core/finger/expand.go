@@ -0,0 +1,109 @@
+package finger
+
+import (
+	"dfinger/common"
+	"github.com/projectdiscovery/gologger"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxExpandTargets 证书域名扩展最多新增的目标数，避免证书中大量域名或链式扩展让扫描失控
+const maxExpandTargets = 4096
+
+// targetExpander 把扫描中证书的 CN 和 SAN 扩展为新的域名目标，只接受范围内的域名，
+// 新目标在发现证书的 IP 和端口上扫描，Host 头和 SNI 使用该域名，每个 域名:端口 只扫描一次
+type targetExpander struct {
+	suffixes []string
+	seen     sync.Map
+	added    atomic.Int64
+	limit    int64
+	full     sync.Once
+	queue    func(tasks []ScanTask)
+}
+
+// newTargetExpander scope 为逗号分隔的域名后缀，输入中已有的域名目标不再重复扫描
+func newTargetExpander(scope string, input []common.UrlInfo) *targetExpander {
+	e := &targetExpander{limit: maxExpandTargets}
+	for _, s := range strings.Split(scope, ",") {
+		s = strings.Trim(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "*."), ".")
+		if s != "" {
+			e.suffixes = append(e.suffixes, s)
+		}
+	}
+	for _, u := range input {
+		if u.IsDomain {
+			e.seen.Store(strings.ToLower(u.Host)+":"+u.Port, true)
+		}
+	}
+	return e
+}
+
+// inScope 判断域名是否等于或属于范围内的某个后缀
+func (e *targetExpander) inScope(name string) bool {
+	for _, s := range e.suffixes {
+		if name == s || strings.HasSuffix(name, "."+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// certNames 证书中可作为目标的域名，通配符取其父域名，IP 和邮箱忽略
+func certNames(cert *CertInfo) []string {
+	var names []string
+	for _, n := range append([]string{cert.CommonName}, cert.SANs...) {
+		n = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(n)), ".")
+		n = strings.TrimPrefix(n, "*.")
+		if n == "" || net.ParseIP(n) != nil || strings.ContainsAny(n, "@=, /*") {
+			continue
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
+// expand 从结果的证书中提取新目标并入队
+func (e *targetExpander) expand(result *ScanResult, task ScanTask) {
+	if result.Cert == nil {
+		return
+	}
+	ip := task.Req.URL.Hostname()
+
+	var tasks []ScanTask
+	for _, name := range certNames(result.Cert) {
+		if !e.inScope(name) {
+			continue
+		}
+		if _, loaded := e.seen.LoadOrStore(name+":"+task.UrlInfo.Port, true); loaded {
+			continue
+		}
+		if e.added.Add(1) > e.limit {
+			e.added.Add(-1)
+			e.full.Do(func() {
+				gologger.Info().Msgf("证书域名扩展已达到上限 %d 个，其余域名不再扫描", e.limit)
+			})
+			break
+		}
+
+		urlInfo := common.UrlInfo{
+			Scheme:   task.UrlInfo.Scheme,
+			Host:     name,
+			Port:     task.UrlInfo.Port,
+			IsDomain: true,
+		}
+		req, err := newDomainRequest(urlInfo, ip)
+		if err != nil {
+			e.added.Add(-1)
+			gologger.Debug().Msgf("构造请求失败: %v", err)
+			continue
+		}
+		gologger.Info().Msgf("证书域名扩展: %s:%s（来自 %s 的证书）", name, urlInfo.Port, result.URL)
+		tasks = append(tasks, ScanTask{Req: req, UrlInfo: urlInfo, Cdninfo: ipCDNInfo(ip)})
+	}
+
+	if len(tasks) > 0 {
+		e.queue(tasks)
+	}
+}
@@ -0,0 +1,137 @@
+package finger
+
+import (
+	"dfinger/common"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCertNames(t *testing.T) {
+	cert := &CertInfo{
+		Subject:    "CN=WWW.Example.com.,O=Example",
+		CommonName: "WWW.Example.com.",
+		SANs: []string{
+			"www.example.com", // 与 CN 重复
+			"*.corp.example.com",
+			"a.*.example.com",
+			"10.0.0.1",
+			"2001:db8::1",
+			"admin@example.com",
+			" api.example.com ",
+			"",
+		},
+	}
+	want := []string{"www.example.com", "www.example.com", "corp.example.com", "api.example.com"}
+	if got := certNames(cert); !reflect.DeepEqual(got, want) {
+		t.Fatalf("certNames = %q，期望 %q", got, want)
+	}
+
+	// 没有 CN 时使用者整体不能作为域名
+	if got := certNames(&CertInfo{Subject: "O=Fortinet,OU=FortiGate"}); got != nil {
+		t.Fatalf("没有 CN 时 certNames = %q", got)
+	}
+}
+
+func TestTargetExpanderScope(t *testing.T) {
+	e := newTargetExpander(" Example.com, *.corp.local ,, .lab. ", nil)
+	if want := []string{"example.com", "corp.local", "lab"}; !reflect.DeepEqual(e.suffixes, want) {
+		t.Fatalf("范围 %q，期望 %q", e.suffixes, want)
+	}
+	tests := map[string]bool{
+		"example.com":     true,
+		"www.example.com": true,
+		"a.b.corp.local":  true,
+		"host.lab":        true,
+		"badexample.com":  false,
+		"example.com.cn":  false,
+		"corp.local.evil": false,
+		"www.example.org": false,
+		"notcorp.local":   false,
+	}
+	for name, want := range tests {
+		if got := e.inScope(name); got != want {
+			t.Errorf("inScope(%q) = %v，期望 %v", name, got, want)
+		}
+	}
+}
+
+// expandTask 在 ip:port 上发现证书的扫描任务
+func expandTask(t *testing.T, ip, port string) ScanTask {
+	t.Helper()
+	req, err := http.NewRequest("GET", "https://"+ip+":"+port+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ScanTask{Req: req, UrlInfo: common.UrlInfo{Scheme: "https", Host: ip, Port: port}}
+}
+
+func TestTargetExpander(t *testing.T) {
+	input := []common.UrlInfo{
+		{Scheme: "https", Host: "Known.example.com", Port: "443", IsDomain: true},
+		{Scheme: "https", Host: "10.0.0.9", Port: "443"},
+	}
+	e := newTargetExpander("example.com", input)
+	var queued []ScanTask
+	e.queue = func(tasks []ScanTask) { queued = append(queued, tasks...) }
+
+	cert := &CertInfo{
+		CommonName: "www.example.com",
+		SANs:       []string{"WWW.example.com", "*.example.com", "known.example.com", "10.0.0.1", "www.other.com", "api.example.com"},
+	}
+	e.expand(&ScanResult{URL: "https://10.0.0.1:443/", Cert: cert}, expandTask(t, "10.0.0.1", "443"))
+	// 同一证书出现在另一个 IP 上不再重复扫描，其他端口作为新目标
+	e.expand(&ScanResult{URL: "https://10.0.0.2:443/", Cert: cert}, expandTask(t, "10.0.0.2", "443"))
+	e.expand(&ScanResult{URL: "https://10.0.0.2:8443/", Cert: &CertInfo{CommonName: "api.example.com"}}, expandTask(t, "10.0.0.2", "8443"))
+	// 没有证书的结果
+	e.expand(&ScanResult{URL: "http://10.0.0.3/"}, expandTask(t, "10.0.0.3", "80"))
+
+	var got []string
+	for _, task := range queued {
+		if !task.UrlInfo.IsDomain || task.Req.Host != task.UrlInfo.Host || task.Cdninfo == nil {
+			t.Errorf("新目标错误: %+v", task)
+		}
+		got = append(got, task.UrlInfo.Scheme+"://"+task.UrlInfo.Host+":"+task.UrlInfo.Port+" @ "+task.Req.URL.Host)
+	}
+	want := []string{
+		"https://www.example.com:443 @ 10.0.0.1:443",
+		"https://example.com:443 @ 10.0.0.1:443",
+		"https://api.example.com:443 @ 10.0.0.1:443",
+		"https://api.example.com:8443 @ 10.0.0.2:8443",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("新目标 %q\n期望 %q", got, want)
+	}
+	if e.added.Load() != 4 {
+		t.Fatalf("新增目标计数 %d，期望 4", e.added.Load())
+	}
+}
+
+func TestTargetExpanderLimit(t *testing.T) {
+	e := newTargetExpander("example.com", nil)
+	e.limit = 3
+	var queued []string
+	e.queue = func(tasks []ScanTask) {
+		for _, task := range tasks {
+			queued = append(queued, task.UrlInfo.Host)
+		}
+	}
+
+	cert := &CertInfo{CommonName: "a.example.com", SANs: []string{"b.example.com"}}
+	e.expand(&ScanResult{Cert: cert}, expandTask(t, "10.0.0.1", "443"))
+	cert = &CertInfo{CommonName: "c.example.com", SANs: []string{"d.example.com", "e.example.com"}}
+	e.expand(&ScanResult{Cert: cert}, expandTask(t, "10.0.0.2", "443"))
+	e.expand(&ScanResult{Cert: &CertInfo{CommonName: "f.example.com"}}, expandTask(t, "10.0.0.3", "443"))
+
+	sort.Strings(queued)
+	if want := []string{"a.example.com", "b.example.com", "c.example.com"}; !reflect.DeepEqual(queued, want) {
+		t.Fatalf("达到上限后仍在扩展: %q", queued)
+	}
+	if e.added.Load() != 3 {
+		t.Fatalf("新增目标计数 %d，期望 3", e.added.Load())
+	}
+	if newTargetExpander("example.com", nil).limit != maxExpandTargets {
+		t.Fatal("默认上限错误")
+	}
+}
@@ -0,0 +1,188 @@
+package finger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultColumns 报表默认列
+const DefaultColumns = "url,status,title,length,server,finger,icon_hash,ip,port,cdn"
+
+// reportColumn 报表的一列
+type reportColumn struct {
+	title   string
+	numeric bool // XLSX 中按数字写入
+	value   func(r *ScanResult) string
+}
+
+// reportColumns 可选的报表列，-columns 按名称选择
+var reportColumns = map[string]reportColumn{
+	"url":       {title: "地址", value: func(r *ScanResult) string { return r.URL }},
+	"final_url": {title: "最终地址", value: func(r *ScanResult) string { return r.FinalURL }},
+	"host":      {title: "主机", value: func(r *ScanResult) string { return r.Host }},
+	"ip":        {title: "IP", value: func(r *ScanResult) string { return r.IP }},
+	"port":      {title: "端口", numeric: true, value: func(r *ScanResult) string { return strconv.Itoa(r.Port) }},
+	"scheme":    {title: "协议", value: func(r *ScanResult) string { return r.Scheme }},
+	"status":    {title: "状态码", numeric: true, value: func(r *ScanResult) string { return strconv.Itoa(r.StatusCode) }},
+	"title":     {title: "标题", value: func(r *ScanResult) string { return r.Title }},
+	"length":    {title: "长度", numeric: true, value: func(r *ScanResult) string { return strconv.Itoa(r.ContentLength) }},
+	"time":      {title: "响应时间(ms)", numeric: true, value: func(r *ScanResult) string { return strconv.FormatInt(r.ResponseTime, 10) }},
+	"server":    {title: "Server", value: func(r *ScanResult) string { return r.Server }},
+	"icon_url":  {title: "图标地址", value: func(r *ScanResult) string { return r.IconURL }},
+	"icon_hash": {title: "图标 Hash", value: func(r *ScanResult) string { return r.IconHash }},
+	"cdn": {title: "CDN", value: func(r *ScanResult) string {
+		if r.CDN.IsCDN {
+			return "是"
+		}
+		return "否"
+	}},
+	"cdn_provider": {title: "CDN 厂商", value: func(r *ScanResult) string { return r.CDN.Provider }},
+	"cnames":       {title: "CNAME", value: func(r *ScanResult) string { return strings.Join(r.CDN.CNAMEs, " -> ") }},
+	"cdn_ips":      {title: "CDN IP", value: func(r *ScanResult) string { return strings.Join(r.CDN.CDNIPs, ",") }},
+	"real_ips":     {title: "真实 IP", value: func(r *ScanResult) string { return strings.Join(r.CDN.RealIPs, ",") }},
+	"finger": {title: "指纹", value: func(r *ScanResult) string {
+		labels := make([]string, len(r.Fingers))
+		for i, f := range r.Fingers {
+			labels[i] = fingerLabel(f)
+		}
+		return strings.Join(labels, ", ")
+	}},
+	"tags": {title: "标签", value: func(r *ScanResult) string {
+		var tags []string
+		for _, f := range r.Fingers {
+			tags = append(tags, f.Tags...)
+		}
+		return strings.Join(uniqueStrings(tags), ", ")
+	}},
+	"generator": {title: "Generator", value: func(r *ScanResult) string { return r.Meta.Generator }},
+	"cert": {title: "证书", value: func(r *ScanResult) string {
+		if r.Cert == nil {
+			return ""
+		}
+		if flags := r.Cert.Flags(); len(flags) > 0 {
+			return r.Cert.Name() + " [" + strings.Join(flags, ",") + "]"
+		}
+		return r.Cert.Name()
+	}},
+	"jarm":      {title: "JARM", value: func(r *ScanResult) string { return r.JARM }},
+	"body_hash": {title: "响应体 Hash", value: func(r *ScanResult) string { return r.BodyHash }},
+	"endpoints": {title: "地址列表", value: func(r *ScanResult) string {
+		urls := make([]string, len(r.Endpoints))
+		for i, e := range r.Endpoints {
+			urls[i] = e.URL
+		}
+		return strings.Join(urls, "\n")
+	}},
+}
+
+// ParseColumns 解析逗号分隔的列名，为空时使用默认列
+func ParseColumns(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultColumns
+	}
+	var columns []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := reportColumns[name]; !ok {
+			return nil, fmt.Errorf("未知的报表列: %q", name)
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("报表列为空")
+	}
+	return columns, nil
+}
+
+// reportHeader 返回各列的表头
+func reportHeader(columns []string) []string {
+	header := make([]string, len(columns))
+	for i, name := range columns {
+		header[i] = reportColumns[name].title
+	}
+	return header
+}
+
+// reportRow 返回结果在各列的取值
+func reportRow(columns []string, r *ScanResult) []string {
+	row := make([]string, len(columns))
+	for i, name := range columns {
+		row[i] = reportColumns[name].value(r)
+	}
+	return row
+}
+
+// uniqueStrings 去重并保持原有顺序
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// CSVSink 逐行写入 CSV 报表
+type CSVSink struct {
+	file    *os.File
+	w       *csv.Writer
+	columns []string
+}
+
+// NewCSVSink 创建 CSV 报表并写入表头，文件带 UTF-8 BOM，Excel 打开中文不乱码
+func NewCSVSink(path string, columns []string) (*CSVSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString("\xEF\xBB\xBF"); err != nil {
+		f.Close()
+		return nil, err
+	}
+	w := &CSVSink{file: f, w: csv.NewWriter(f), columns: columns}
+	if err := w.w.Write(reportHeader(columns)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *CSVSink) Write(r *ScanResult) error {
+	row := reportRow(w.columns, r)
+	for i, name := range w.columns {
+		if !reportColumns[name].numeric {
+			row[i] = csvSafe(row[i])
+		}
+	}
+	return w.w.Write(row)
+}
+
+// csvSafe 标题、Server 等来自目标的内容以 = + - @ 等开头时会被 Excel 当作公式执行，前面加 ' 按文本处理
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (w *CSVSink) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *CSVSink) Close() error {
+	if err := w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
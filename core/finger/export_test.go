@@ -0,0 +1,211 @@
+package finger
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVSinkFormulaCells(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.csv")
+	w, err := NewCSVSink(path, []string{"url", "title", "server", "status", "length", "finger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &ScanResult{
+		URL:           "http://example.com/",
+		Title:         `=HYPERLINK("http://evil/","x")`,
+		Server:        "+cmd|' /C calc'!A0",
+		StatusCode:    200,
+		ContentLength: -1,
+		Fingers:       []DetectionResult{{CMS: "@SUM(1+1)", Level: 3}},
+	}
+	if err := w.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	r2 := &ScanResult{URL: "-2+3", Title: "\tTab", Server: "\rCR", Fingers: []DetectionResult{{CMS: "nginx", Level: 3}}}
+	if err := w.Write(r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		reportHeader([]string{"url", "title", "server", "status", "length", "finger"}),
+		{"http://example.com/", `'=HYPERLINK("http://evil/","x")`, "'+cmd|' /C calc'!A0", "200", "-1", "'@SUM(1+1)(L3)"},
+		{"'-2+3", "'\tTab", "'\rCR", "0", "0", "nginx(L3)"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("CSV 内容 %q，期望 %q", rows, want)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for i, want := range tests {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d) = %s，期望 %s", i, got, want)
+		}
+	}
+}
+
+// xlsxSheet 测试中解析的工作表 XML
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			S      string `xml:"s,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	AutoFilter struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"autoFilter"`
+}
+
+func TestXLSXSink(t *testing.T) {
+	// 53 列，覆盖 Z 之后的 AA、AZ、BA
+	columns := []string{"url", "status", "title"}
+	for len(columns) < 53 {
+		columns = append(columns, "server")
+	}
+	path := filepath.Join(t.TempDir(), "result.xlsx")
+	w, err := NewXLSXSink(path, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []*ScanResult{
+		{URL: "http://a/", StatusCode: 200, Port: 80, Title: "a <b> & \"c\"\x00\x01\x1b\td\ne", Server: "=1+1",
+			Fingers: []DetectionResult{{CMS: "nginx", Level: 3}, {CMS: "nginx", Level: 3}}},
+		{URL: "http://b/", StatusCode: 404, Port: 80, Title: "b", Fingers: []DetectionResult{{CMS: "nginx", Level: 3}, {CMS: "tomcat", Level: 3}}},
+	}
+	for _, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+		// 每个部件都必须是合法 XML
+		d := xml.NewDecoder(strings.NewReader(parts[f.Name]))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s 不是合法 XML: %v", f.Name, err)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("缺少 %s", name)
+		}
+	}
+
+	var types struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.Unmarshal([]byte(parts["[Content_Types].xml"]), &types); err != nil {
+		t.Fatal(err)
+	}
+	overrides := make(map[string]string)
+	for _, o := range types.Overrides {
+		overrides[o.PartName] = o.ContentType
+	}
+	for _, name := range []string{"/xl/worksheets/sheet1.xml", "/xl/worksheets/sheet2.xml"} {
+		if overrides[name] != "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml" {
+			t.Fatalf("[Content_Types].xml 中 %s 类型为 %q", name, overrides[name])
+		}
+	}
+	if !strings.Contains(overrides["/xl/workbook.xml"], "spreadsheetml.sheet.main+xml") {
+		t.Fatalf("[Content_Types].xml 中缺少 workbook")
+	}
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("结果工作表 %d 行，期望 3 行", len(sheet.Rows))
+	}
+	header, first := sheet.Rows[0].Cells, sheet.Rows[1].Cells
+	if len(header) != 53 || header[0].S != "1" || header[0].Inline != "地址" {
+		t.Fatalf("表头错误: %+v", header[0])
+	}
+	for i, ref := range map[int]string{25: "Z2", 26: "AA2", 51: "AZ2", 52: "BA2"} {
+		if first[i].R != ref {
+			t.Errorf("第 %d 列引用 %s，期望 %s", i, first[i].R, ref)
+		}
+	}
+	if sheet.AutoFilter.Ref != "A1:BA3" {
+		t.Errorf("筛选范围 %s，期望 A1:BA3", sheet.AutoFilter.Ref)
+	}
+	if first[1].T != "" || first[1].V != "200" {
+		t.Errorf("状态码应为数值单元格: %+v", first[1])
+	}
+	// XLSX 使用内联字符串，不做 CSV 的公式前缀
+	if first[2].T != "inlineStr" || first[2].Inline != "a <b> & \"c\"\td\ne" || first[3].Inline != "=1+1" {
+		t.Errorf("文本单元格错误: %q %q", first[2].Inline, first[3].Inline)
+	}
+	raw := parts["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(raw, "a &lt;b&gt; &amp; &#34;c&#34;") || strings.ContainsAny(raw, "\x00\x01\x1b") {
+		t.Errorf("转义错误: %s", raw)
+	}
+
+	var summary xlsxSheet
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet2.xml"]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, row := range summary.Rows {
+		var cells []string
+		for _, c := range row.Cells {
+			cells = append(cells, c.Inline+c.V)
+		}
+		got = append(got, strings.Join(cells, "|"))
+	}
+	want := []string{"指纹|目标数", "nginx|2", "tomcat|1", "端口|目标数", "80|2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("统计工作表 %q，期望 %q", got, want)
+	}
+}
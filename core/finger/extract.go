@@ -0,0 +1,82 @@
+package finger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extractor 版本提取器，从指定位置用正则捕获组提取版本号
+type Extractor struct {
+	Location string `json:"location" yaml:"location"`               // 同 Condition.Location
+	Regex    string `json:"regex" yaml:"regex"`                     // 提取正则，不区分大小写
+	Group    string `json:"group,omitempty" yaml:"group,omitempty"` // 捕获组名或序号，为空时优先取 version 命名组，否则取第1组
+}
+
+type compiledExtractor struct {
+	location int
+	re       *regexp.Regexp
+	group    int
+}
+
+func (rs *RuleSet) compileExtractors(extractors []Extractor) ([]compiledExtractor, error) {
+	var compiled []compiledExtractor
+	var errs []error
+	for i, ex := range extractors {
+		ce, err := rs.compileExtractor(ex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("提取器%d: %w", i, err))
+			continue
+		}
+		compiled = append(compiled, ce)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+func (rs *RuleSet) compileExtractor(ex Extractor) (compiledExtractor, error) {
+	loc, err := rs.location(ex.Location)
+	if err != nil {
+		return compiledExtractor{}, err
+	}
+	re, err := regexp.Compile("(?i)" + ex.Regex)
+	if err != nil {
+		return compiledExtractor{}, fmt.Errorf("无效的正则 %q: %w", ex.Regex, err)
+	}
+
+	group := -1
+	switch {
+	case ex.Group == "":
+		if group = re.SubexpIndex("version"); group < 0 && re.NumSubexp() > 0 {
+			group = 1
+		} else if group < 0 {
+			group = 0
+		}
+	default:
+		if n, err := strconv.Atoi(ex.Group); err == nil {
+			group = n
+		} else {
+			group = re.SubexpIndex(ex.Group)
+		}
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return compiledExtractor{}, fmt.Errorf("正则 %q 中不存在捕获组 %q", ex.Regex, ex.Group)
+	}
+
+	return compiledExtractor{location: loc, re: re, group: group}, nil
+}
+
+// extractVersion 按顺序执行提取器，返回第一个非空结果
+func extractVersion(extractors []compiledExtractor, mc *matchContext) string {
+	for i := range extractors {
+		ex := &extractors[i]
+		m := ex.re.FindStringSubmatch(mc.dataAt(ex.location))
+		if len(m) > ex.group {
+			if v := strings.TrimSpace(m[ex.group]); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
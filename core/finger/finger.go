@@ -1,110 +1,424 @@
 package finger
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/projectdiscovery/gologger"
-	"net/http"
-	"os"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 	"sync"
 )
 
-// Condition 单个匹配条件
+// Condition 单个匹配条件，设置了 Conditions 时作为嵌套条件组
 type Condition struct {
-	Location string   `json:"location"` // header, body, title, favicon, path
-	Matcher  string   `json:"matcher"`  // match, regex  支持关键字匹配、正则匹配
-	Keywords []string `json:"keywords"` // 关键字列表
+	Location string   `json:"location,omitempty" yaml:"location,omitempty"` // header, body, title, favicon, path
+	Matcher  string   `json:"matcher,omitempty" yaml:"matcher,omitempty"`   // match, regex  支持关键字匹配、正则匹配
+	Keywords []string `json:"keywords,omitempty" yaml:"keywords,omitempty"` // 关键字列表
+	Not      bool     `json:"not,omitempty" yaml:"not,omitempty"`           // 取反，条件不满足时视为命中
+
+	Logic      string      `json:"logic,omitempty" yaml:"logic,omitempty"`           // 条件组内部逻辑 "and" 或 "or"
+	Conditions []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"` // 子条件组
 }
 
 // FingerprintRule 指纹规则
 type FingerprintRule struct {
-	CMS        string      `json:"cms"`        // CMS名
-	Level      int         `json:"level"`      // 置信度 1-5
-	Logic      string      `json:"logic"`      // "and" 或 "or"
-	Tags       []string    `json:"tags"`       // 标签
-	Conditions []Condition `json:"conditions"` // 条件数组
+	CMS        string       `json:"cms" yaml:"cms"`                                   // CMS名
+	Level      int          `json:"level" yaml:"level"`                               // 置信度 1-5
+	Logic      string       `json:"logic" yaml:"logic"`                               // "and" 或 "or"
+	Tags       []string     `json:"tags" yaml:"tags"`                                 // 标签
+	Conditions []Condition  `json:"conditions" yaml:"conditions"`                     // 条件数组
+	Extractors []Extractor  `json:"extractors,omitempty" yaml:"extractors,omitempty"` // 版本提取器，规则命中后按顺序提取
+	Probe      *Probe       `json:"probe,omitempty" yaml:"probe,omitempty"`           // 主动探测请求，设置后条件针对探测响应匹配
+	Samples    *RuleSamples `json:"samples,omitempty" yaml:"samples,omitempty"`       // 回归测试样本，供 rules test 使用
 }
 
-var Rules []FingerprintRule
+// Detector 全局共享的检测器，所有 worker 复用同一份编译后的规则
+var Detector *FingerprintDetector
 
 // DetectionResult 检测结果
 type DetectionResult struct {
-	CMS     string
-	Level   int
-	Tags    []string
-	Matched []string // 匹配上的关键词
+	CMS     string   `json:"cms"`
+	Level   int      `json:"level"`
+	Tags    []string `json:"tags"`
+	Matched []string `json:"matched"`           // 匹配上的关键词
+	Version string   `json:"version,omitempty"` // 提取到的版本号
 }
 
-// FingerprintDetector 检测器
-type FingerprintDetector struct {
-	rules []FingerprintRule
-	mu    sync.RWMutex
+// compiledCondition 预编译后的条件：match 关键字编入所在位置的自动机，regex 提前编译
+type compiledCondition struct {
+	not bool
+
+	// 条件组
+	logicOr bool
+	sub     []compiledCondition
+
+	location int
+	matcher  string
+	keywords []string         // 原始关键字，用于输出匹配结果
+	ids      []int32          // match 关键字在自动机中的编号，-1 表示空关键字
+	regexps  []*regexp.Regexp // regex 使用的已编译正则
+	statuses []statusMatcher  // status 位置使用 match 时的数值比较
+	literals []int32          // 正则必须包含的字面量在自动机中的编号，未命中时跳过正则，-1 表示无
 }
 
-func NewDetector(rules []FingerprintRule) *FingerprintDetector {
-	return &FingerprintDetector{
-		rules: rules,
+// compiledRule 预编译后的规则
+type compiledRule struct {
+	rule       *FingerprintRule
+	logicOr    bool
+	conditions []compiledCondition
+	extractors []compiledExtractor
+	probe      int // 主动探测在 RuleSet.probes 中的下标，-1 表示使用首页响应
+}
+
+// RuleSet 编译后的规则集，只读，可在多个 goroutine 间共享
+type RuleSet struct {
+	Rules    []FingerprintRule
+	compiled []compiledRule
+
+	locations []locationSlot // 规则中用到的匹配位置
+	locIndex  map[string]int
+	matchers  []*acMatcher // 每个位置一个 match 关键字自动机
+
+	passive    []int          // 针对首页响应的规则
+	probes     []Probe        // 去重后的主动探测请求
+	probeIndex map[string]int // 探测请求 key -> 下标
+	probeRules [][]int        // 每个探测请求对应的规则
+}
+
+// Len 返回规则数量
+func (rs *RuleSet) Len() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.Rules)
+}
+
+// RuleError 单条规则的编译错误，Errs 中每一项是一个独立的问题
+type RuleError struct {
+	Source string
+	Index  int
+	CMS    string
+	Errs   []error
+}
+
+func (e *RuleError) Error() string {
+	lines := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		lines[i] = fmt.Sprintf("%s: 第%d条规则(%s): %v", e.Source, e.Index, e.CMS, err)
 	}
+	return strings.Join(lines, "\n")
 }
 
-// matchCondition 判断单个条件是否满足，返回匹配上的关键词列表
-// matchCondition 判断单个条件是否满足，返回匹配上的关键词列表
-func matchCondition(cond Condition, resp *http.Response, body string, title string, faviconHash string, path string) (matched []string, ok bool) {
-	var data string
+// CompileRules 编译规则，source 用于错误信息中标明规则来源
+// 编译失败时返回由 *RuleError 组成的 errors.Join 错误，包含全部规则的全部问题
+func CompileRules(rules []FingerprintRule, source string) (*RuleSet, error) {
+	rs := &RuleSet{
+		Rules:      rules,
+		compiled:   make([]compiledRule, 0, len(rules)),
+		locIndex:   make(map[string]int),
+		probeIndex: make(map[string]int),
+	}
 
-	switch cond.Location {
-	case "header":
-		// 合并所有响应头字段内容
-		var headers []string
-		for k := range resp.Header {
-			headers = append(headers, k+": "+strings.Join(resp.Header.Values(k), ","))
+	var errs []error
+	for i := range rs.Rules {
+		cr, err := rs.compileRule(&rs.Rules[i])
+		if err != nil {
+			errs = append(errs, &RuleError{Source: source, Index: i, CMS: rs.Rules[i].CMS, Errs: flattenErrors(err)})
+			continue
 		}
-		data = strings.Join(headers, "\n")
+		if cr.probe >= 0 {
+			rs.probeRules[cr.probe] = append(rs.probeRules[cr.probe], len(rs.compiled))
+		} else {
+			rs.passive = append(rs.passive, len(rs.compiled))
+		}
+		rs.compiled = append(rs.compiled, cr)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
 
-	case "body":
-		data = body
+	for loc, m := range rs.matchers {
+		if m.Len() == 0 {
+			rs.matchers[loc] = nil
+			continue
+		}
+		m.build()
+	}
+	return rs, nil
+}
 
-	case "title":
-		data = title
+// flattenErrors 展开 errors.Join 产生的嵌套错误
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, flattenErrors(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
 
-	case "favicon":
-		data = faviconHash
-	case "path":
-		data = path
+// prefixErrors 给每个错误加上位置前缀，如 "条件1: 条件0: 无效的正则"
+func prefixErrors(prefix string, err error) error {
+	errs := flattenErrors(err)
+	for i, e := range errs {
+		errs[i] = fmt.Errorf("%s: %w", prefix, e)
+	}
+	return errors.Join(errs...)
+}
 
+func (rs *RuleSet) compileRule(rule *FingerprintRule) (compiledRule, error) {
+	cr := compiledRule{rule: rule, probe: -1}
+
+	var errs []error
+	var err error
+	if cr.logicOr, err = parseLogic(rule.Logic); err != nil {
+		errs = append(errs, err)
+	}
+	if rule.Probe != nil {
+		if cr.probe, err = rs.addProbe(rule.Probe); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cr.conditions, err = rs.compileConditions(rule.Conditions); err != nil {
+		errs = append(errs, err)
+	}
+	if cr.extractors, err = rs.compileExtractors(rule.Extractors); err != nil {
+		errs = append(errs, err)
+	}
+	return cr, errors.Join(errs...)
+}
+
+// parseLogic 解析条件逻辑，为空时默认 and
+func parseLogic(logic string) (or bool, err error) {
+	switch strings.ToLower(logic) {
+	case "", "and":
+		return false, nil
+	case "or":
+		return true, nil
+	}
+	return false, fmt.Errorf("未知的逻辑: %q", logic)
+}
+
+func (rs *RuleSet) compileConditions(conds []Condition) ([]compiledCondition, error) {
+	compiled := make([]compiledCondition, 0, len(conds))
+	var errs []error
+	for j, cond := range conds {
+		cc, err := rs.compileCondition(cond)
+		if err != nil {
+			errs = append(errs, prefixErrors(fmt.Sprintf("条件%d", j), err))
+			continue
+		}
+		compiled = append(compiled, cc)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+func (rs *RuleSet) compileCondition(cond Condition) (compiledCondition, error) {
+	if len(cond.Conditions) > 0 {
+		var errs []error
+		if cond.Location != "" || cond.Matcher != "" || len(cond.Keywords) > 0 {
+			errs = append(errs, fmt.Errorf("条件组不能同时设置 location/matcher/keywords"))
+		}
+		logicOr, err := parseLogic(cond.Logic)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		sub, err := rs.compileConditions(cond.Conditions)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return compiledCondition{not: cond.Not, logicOr: logicOr, sub: sub}, errors.Join(errs...)
+	}
+
+	var errs []error
+	loc, err := rs.location(cond.Location)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	switch cond.Matcher {
+	case "match", "regex":
 	default:
-		return nil, false
+		errs = append(errs, fmt.Errorf("未知的匹配方式: %q", cond.Matcher))
+	}
+	if len(errs) > 0 {
+		return compiledCondition{}, errors.Join(errs...)
 	}
 
-	allMatch := true
-	for _, keyword := range cond.Keywords {
-		switch cond.Matcher {
-		case "match":
-			if !strings.Contains(strings.ToLower(data), strings.ToLower(keyword)) {
-				allMatch = false
-				break
+	cc := compiledCondition{
+		not:      cond.Not,
+		location: loc,
+		matcher:  cond.Matcher,
+		keywords: cond.Keywords,
+	}
+
+	switch cond.Matcher {
+	case "match":
+		if rs.locations[loc].kind == locStatus {
+			for _, keyword := range cond.Keywords {
+				sm, err := parseStatusMatcher(keyword)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				cc.statuses = append(cc.statuses, sm)
+			}
+			break
+		}
+		for _, keyword := range cond.Keywords {
+			if keyword == "" {
+				cc.ids = append(cc.ids, -1)
+				continue
+			}
+			cc.ids = append(cc.ids, rs.matchers[loc].add(strings.ToLower(keyword)))
+		}
+	case "regex":
+		for _, keyword := range cond.Keywords {
+			re, err := regexp.Compile("(?i)" + keyword) // (?i) 表示不区分大小写
+			if err != nil {
+				errs = append(errs, fmt.Errorf("无效的正则 %q: %w", keyword, err))
+				continue
 			}
-			gologger.Debug().Msgf("匹配到指纹关键字（match）：%v", keyword)
-			matched = append(matched, keyword)
+			cc.regexps = append(cc.regexps, re)
 
-		case "regex":
-			match, _ := regexp.MatchString("(?i)"+keyword, data) // (?i) 表示不区分大小写
-			if !match {
-				allMatch = false
-				break
+			lit := int32(-1)
+			if l := regexLiteral(keyword); l != "" {
+				lit = rs.matchers[loc].add(l)
 			}
-			gologger.Debug().Msgf("匹配到指纹关键字（regex）：%v", keyword)
-			matched = append(matched, keyword)
+			cc.literals = append(cc.literals, lit)
+		}
+	}
+	return cc, errors.Join(errs...)
+}
+
+// regexLiteral 提取正则中必须出现的最长 ASCII 字面量（小写），用作自动机预过滤
+// 只分析顶层的连接结构，无法确定时返回空串
+func regexLiteral(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
 
-		default:
-			return nil, false
+	var parts []*syntax.Regexp
+	switch re.Op {
+	case syntax.OpConcat:
+		parts = re.Sub
+	case syntax.OpLiteral:
+		parts = []*syntax.Regexp{re}
+	}
+
+	best := ""
+	for _, part := range parts {
+		if part.Op != syntax.OpLiteral {
+			continue
 		}
+		lit := strings.ToLower(string(part.Rune))
+		if len(lit) > len(best) && isASCII(lit) {
+			best = lit
+		}
+	}
+	if len(best) < 3 {
+		return ""
 	}
+	return best
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchConditions 按 and/or 逻辑判断一组条件，返回匹配上的关键词列表
+func matchConditions(conds []compiledCondition, logicOr bool, mc *matchContext) (matched []string, ok bool) {
+	allMatched := !logicOr
+	for i := range conds {
+		kws, hit := matchCondition(&conds[i], mc)
 
-	if !allMatch {
+		if logicOr {
+			if hit {
+				allMatched = true
+				matched = append(matched, kws...)
+				break
+			}
+		} else {
+			// 默认and逻辑
+			if !hit {
+				return nil, false
+			}
+			matched = append(matched, kws...)
+		}
+	}
+	if !allMatched {
 		return nil, false
 	}
+	return matched, true
+}
+
+// matchCondition 判断单个条件或条件组是否满足，返回匹配上的关键词列表
+// 取反条件命中时不返回关键词
+func matchCondition(cond *compiledCondition, mc *matchContext) (matched []string, ok bool) {
+	if len(cond.sub) > 0 {
+		matched, ok = matchConditions(cond.sub, cond.logicOr, mc)
+	} else {
+		matched, ok = matchKeywords(cond, mc)
+	}
+	if cond.not {
+		return nil, !ok
+	}
+	return matched, ok
+}
+
+// matchKeywords 判断单个条件的关键字是否全部命中
+func matchKeywords(cond *compiledCondition, mc *matchContext) (matched []string, ok bool) {
+	switch {
+	case len(cond.statuses) > 0:
+		code := 0
+		if mc.input.Resp != nil {
+			code = mc.input.Resp.StatusCode
+		}
+		for i, sm := range cond.statuses {
+			if !sm.match(code) {
+				return nil, false
+			}
+			matched = append(matched, cond.keywords[i])
+		}
+
+	case cond.matcher == "match":
+		hits := mc.hitsAt(cond.location)
+		for i, id := range cond.ids {
+			if id >= 0 && !hasHit(hits, id) {
+				return nil, false
+			}
+			gologger.Debug().Msgf("匹配到指纹关键字（match）：%v", cond.keywords[i])
+			matched = append(matched, cond.keywords[i])
+		}
+
+	case cond.matcher == "regex":
+		data := mc.dataAt(cond.location)
+		for i, re := range cond.regexps {
+			if lit := cond.literals[i]; lit >= 0 && !hasHit(mc.hitsAt(cond.location), lit) {
+				return nil, false
+			}
+			if !re.MatchString(data) {
+				return nil, false
+			}
+			gologger.Debug().Msgf("匹配到指纹关键字（regex）：%v", cond.keywords[i])
+			matched = append(matched, cond.keywords[i])
+		}
+	}
 
 	if len(matched) == 0 {
 		return nil, false
@@ -112,68 +426,64 @@ func matchCondition(cond Condition, resp *http.Response, body string, title stri
 	return matched, true
 }
 
-// Detect 对单个响应进行指纹检测
-func (fd *FingerprintDetector) Detect(resp *http.Response, body []byte, title string, faviconHash string, path string) []DetectionResult {
+// FingerprintDetector 检测器
+type FingerprintDetector struct {
+	rules *RuleSet
+	mu    sync.RWMutex
+}
+
+func NewDetector(rules *RuleSet) *FingerprintDetector {
+	return &FingerprintDetector{
+		rules: rules,
+	}
+}
+
+// Rules 返回当前使用的规则集
+func (fd *FingerprintDetector) Rules() *RuleSet {
 	fd.mu.RLock()
 	defer fd.mu.RUnlock()
+	return fd.rules
+}
 
-	bodyStr := string(body)
-	var results []DetectionResult
+// Detect 对单个响应进行指纹检测
+func (fd *FingerprintDetector) Detect(input *ResponseData) []DetectionResult {
+	return fd.Rules().Detect(input)
+}
 
-	for _, rule := range fd.rules {
+// Detect 用不带主动探测的规则检测首页响应
+func (rs *RuleSet) Detect(input *ResponseData) []DetectionResult {
+	if rs == nil {
+		return nil
+	}
+	return rs.detect(rs.passive, input)
+}
 
-		allMatched := (rule.Logic == "and")
-		var matchedKeywords []string
+// DetectProbe 用第 probe 个主动探测对应的规则检测探测响应
+func (rs *RuleSet) DetectProbe(probe int, input *ResponseData) []DetectionResult {
+	if rs == nil || probe < 0 || probe >= len(rs.probeRules) {
+		return nil
+	}
+	return rs.detect(rs.probeRules[probe], input)
+}
 
-		for _, cond := range rule.Conditions {
-			matched, ok := matchCondition(cond, resp, bodyStr, title, faviconHash, path)
+func (rs *RuleSet) detect(indices []int, input *ResponseData) []DetectionResult {
+	mc := newMatchContext(rs, input)
+	var results []DetectionResult
 
-			if rule.Logic == "and" {
-				if !ok {
-					allMatched = false
-					break
-				} else {
-					matchedKeywords = append(matchedKeywords, matched...)
-				}
-			} else if rule.Logic == "or" {
-				if ok {
-					allMatched = true
-					matchedKeywords = append(matchedKeywords, matched...)
-					break
-				} else {
-					allMatched = false
-				}
-			} else {
-				// 默认and逻辑
-				if !ok {
-					allMatched = false
-					break
-				} else {
-					matchedKeywords = append(matchedKeywords, matched...)
-				}
-			}
-		}
+	for _, i := range indices {
+		rule := &rs.compiled[i]
 
-		if allMatched {
+		matchedKeywords, ok := matchConditions(rule.conditions, rule.logicOr, mc)
+		if ok {
 			results = append(results, DetectionResult{
-				CMS:     rule.CMS,
-				Level:   rule.Level,
-				Tags:    rule.Tags,
+				CMS:     rule.rule.CMS,
+				Level:   rule.rule.Level,
+				Tags:    rule.rule.Tags,
 				Matched: matchedKeywords,
+				Version: extractVersion(rule.extractors, mc),
 			})
 		}
 	}
 
 	return results
 }
-
-// LoadFingerprints 从文件加载规则
-func LoadFingerprints(path string) ([]FingerprintRule, error) {
-	var fps []FingerprintRule
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal(data, &fps)
-	return fps, err
-}
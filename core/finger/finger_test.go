@@ -0,0 +1,186 @@
+package finger
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// conditionInput 条件测试使用的响应：nginx + Shiro cookie + 登录页
+func conditionInput() *ResponseData {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Server":     {"nginx/1.20.1"},
+			"Set-Cookie": {"rememberMe=deleteMe; Path=/"},
+		},
+	}
+	return &ResponseData{
+		Resp:  resp,
+		Body:  `<html><head><title>Admin Login</title></head><body><form><input type="password"></form>Powered by Acme CMS v2.3</body></html>`,
+		Title: "Admin Login",
+		Path:  "/",
+	}
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		matched []string // nil 表示不命中
+	}{
+		{
+			name:    "旧版平铺格式 and",
+			rule:    `{"cms":"x","level":1,"logic":"and","tags":[],"conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["acme cms"]}]}`,
+			matched: []string{"nginx", "acme cms"},
+		},
+		{
+			name:    "旧版平铺格式 and 一个条件不满足",
+			rule:    `{"cms":"x","level":1,"logic":"and","tags":[],"conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["wordpress"]}]}`,
+			matched: nil,
+		},
+		{
+			name:    "旧版平铺格式 or 取第一个命中的条件",
+			rule:    `{"cms":"x","level":1,"logic":"or","tags":[],"conditions":[{"location":"body","matcher":"match","keywords":["wordpress"]},{"location":"title","matcher":"regex","keywords":["admin\\s+login"]},{"location":"header","matcher":"match","keywords":["nginx"]}]}`,
+			matched: []string{`admin\s+login`},
+		},
+		{
+			name:    "旧版平铺格式 条件内关键字全部命中",
+			rule:    `{"cms":"x","level":1,"logic":"and","tags":[],"conditions":[{"location":"body","matcher":"match","keywords":["password","acme"]}]}`,
+			matched: []string{"password", "acme"},
+		},
+		{
+			name:    "旧版平铺格式 条件内关键字部分命中",
+			rule:    `{"cms":"x","level":1,"logic":"and","tags":[],"conditions":[{"location":"body","matcher":"match","keywords":["password","wordpress"]}]}`,
+			matched: nil,
+		},
+		{
+			name:    "logic 为空默认 and",
+			rule:    `{"cms":"x","level":1,"conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["wordpress"]}]}`,
+			matched: nil,
+		},
+		{
+			name:    "not 取反命中不返回关键字",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["wordpress"],"not":true}]}`,
+			matched: []string{"nginx"},
+		},
+		{
+			name:    "not 取反条件满足时不命中",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["acme"],"not":true}]}`,
+			matched: nil,
+		},
+		{
+			name:    "and 中嵌套 or 组",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"server","matcher":"match","keywords":["nginx"]},{"logic":"or","conditions":[{"location":"body","matcher":"match","keywords":["wordpress"]},{"location":"cookie","matcher":"match","keywords":["rememberMe=deleteMe"]}]}]}`,
+			matched: []string{"nginx", "rememberMe=deleteMe"},
+		},
+		{
+			name:    "and 中嵌套 or 组全部不满足",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"server","matcher":"match","keywords":["nginx"]},{"logic":"or","conditions":[{"location":"body","matcher":"match","keywords":["wordpress"]},{"location":"cookie","matcher":"match","keywords":["JSESSIONID"]}]}]}`,
+			matched: nil,
+		},
+		{
+			name:    "or 中嵌套 and 组",
+			rule:    `{"cms":"x","level":1,"logic":"or","conditions":[{"location":"title","matcher":"match","keywords":["dashboard"]},{"logic":"and","conditions":[{"location":"status","matcher":"match","keywords":["200"]},{"location":"body","matcher":"regex","keywords":["acme cms v\\d"]}]}]}`,
+			matched: []string{"200", `acme cms v\d`},
+		},
+		{
+			name:    "取反的条件组",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"title","matcher":"match","keywords":["login"]},{"not":true,"logic":"and","conditions":[{"location":"header","matcher":"match","keywords":["nginx"]},{"location":"body","matcher":"match","keywords":["wordpress"]}]}]}`,
+			matched: []string{"login"},
+		},
+		{
+			name:    "三层嵌套",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"logic":"or","conditions":[{"location":"body","matcher":"match","keywords":["wordpress"]},{"logic":"and","conditions":[{"location":"header:Server","matcher":"regex","keywords":["nginx/1\\.\\d+"]},{"location":"status","matcher":"match","keywords":["200-299"]}]}]}]}`,
+			matched: []string{`nginx/1\.\d+`, "200-299"},
+		},
+		{
+			name:    "只有取反条件时命中但无关键字",
+			rule:    `{"cms":"x","level":1,"logic":"and","conditions":[{"location":"body","matcher":"match","keywords":["wordpress"],"not":true}]}`,
+			matched: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule FingerprintRule
+			if err := json.Unmarshal([]byte(tt.rule), &rule); err != nil {
+				t.Fatal(err)
+			}
+			rs, err := CompileRules([]FingerprintRule{rule}, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := rs.Detect(conditionInput())
+			if tt.matched == nil {
+				if len(results) != 0 {
+					t.Fatalf("不应命中，实际命中 %v", results[0].Matched)
+				}
+				return
+			}
+			if len(results) != 1 {
+				t.Fatalf("应命中，实际结果 %d 个", len(results))
+			}
+			if len(results[0].Matched) != len(tt.matched) || len(tt.matched) > 0 && !reflect.DeepEqual(results[0].Matched, tt.matched) {
+				t.Fatalf("匹配关键字 %q，期望 %q", results[0].Matched, tt.matched)
+			}
+		})
+	}
+}
+
+func TestCompileConditionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"未知位置", `{"cms":"x","conditions":[{"location":"bodyy","matcher":"match","keywords":["a"]}]}`},
+		{"未知匹配方式", `{"cms":"x","conditions":[{"location":"body","matcher":"contains","keywords":["a"]}]}`},
+		{"无效正则", `{"cms":"x","conditions":[{"location":"body","matcher":"regex","keywords":["("]}]}`},
+		{"未知逻辑", `{"cms":"x","logic":"xor","conditions":[{"location":"body","matcher":"match","keywords":["a"]}]}`},
+		{"条件组同时设置关键字", `{"cms":"x","conditions":[{"location":"body","keywords":["a"],"conditions":[{"location":"body","matcher":"match","keywords":["a"]}]}]}`},
+		{"嵌套组中的错误", `{"cms":"x","conditions":[{"logic":"or","conditions":[{"location":"body","matcher":"regex","keywords":["[a"]}]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule FingerprintRule
+			if err := json.Unmarshal([]byte(tt.rule), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := CompileRules([]FingerprintRule{rule}, "test"); err == nil {
+				t.Fatal("应返回编译错误")
+			}
+		})
+	}
+}
+
+func TestFlatMethodFormat(t *testing.T) {
+	data := []byte(`{"fingerprint":[
+		{"cms":"nginx","method":"keyword","location":"header","keyword":["nginx"]},
+		{"cms":"acme","method":"keyword","location":"body","keyword":["Acme CMS","password"]},
+		{"cms":"acme-version","method":"regular","location":"body","keyword":["Acme CMS v\\d+\\.\\d+"]},
+		{"cms":"admin","method":"keyword","location":"title","keyword":["Admin"]},
+		{"cms":"wordpress","method":"keyword","location":"body","keyword":["wp-content"]},
+		{"cms":"partial","method":"keyword","location":"body","keyword":["Acme CMS","wp-content"]}
+	]}`)
+	rules, issues, err := ParseRules("finger.json", data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("不应有转换问题: %v", issues)
+	}
+	rs, err := CompileRules(rules, "finger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range rs.Detect(conditionInput()) {
+		got = append(got, r.CMS)
+	}
+	want := []string{"nginx", "acme", "acme-version", "admin"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("命中 %v，期望 %v", got, want)
+	}
+}
@@ -0,0 +1,316 @@
+package finger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTMLSink 单文件 HTML 报告，样式和脚本内嵌，结果先缓存在内存中，Close 时生成文件
+type HTMLSink struct {
+	path    string
+	start   time.Time
+	records []htmlRecord
+}
+
+// htmlRecord 报告中的一行，字段名与页面脚本对应
+type htmlRecord struct {
+	URL     string       `json:"url"`
+	Final   string       `json:"final"`
+	IP      string       `json:"ip"`
+	Port    int          `json:"port"`
+	Status  int          `json:"status"`
+	Title   string       `json:"title"`
+	Length  int          `json:"length"`
+	Time    int64        `json:"time"`
+	Server  string       `json:"server"`
+	Icon    string       `json:"icon"` // data URI
+	Hash    string       `json:"hash"`
+	CDN     bool         `json:"cdn"`
+	CDNName string       `json:"cdn_provider"`
+	CNAMEs  []string     `json:"cnames"`
+	CDNIPs  []string     `json:"cdn_ips"`
+	RealIPs []string     `json:"real_ips"`
+	Cert    string       `json:"cert"`
+	Fingers []htmlFinger `json:"fingers"`
+	Headers []string     `json:"headers"`
+	Ends    []string     `json:"endpoints"`
+}
+
+type htmlFinger struct {
+	CMS     string   `json:"cms"`
+	Version string   `json:"version"`
+	Level   int      `json:"level"`
+	Tags    []string `json:"tags"`
+	Matched []string `json:"matched"`
+}
+
+// NewHTMLSink 创建 HTML 报告，先检查文件可写
+func NewHTMLSink(path string) (*HTMLSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return &HTMLSink{path: path, start: time.Now()}, nil
+}
+
+func (w *HTMLSink) Write(r *ScanResult) error {
+	rec := htmlRecord{
+		URL:     r.URL,
+		Final:   r.FinalURL,
+		IP:      r.IP,
+		Port:    r.Port,
+		Status:  r.StatusCode,
+		Title:   r.Title,
+		Length:  r.ContentLength,
+		Time:    r.ResponseTime,
+		Server:  r.Server,
+		Icon:    iconDataURI(r.Icon),
+		Hash:    r.IconHash,
+		CDN:     r.CDN.IsCDN,
+		CDNName: r.CDN.Provider,
+		CNAMEs:  append([]string{}, r.CDN.CNAMEs...),
+		CDNIPs:  append([]string{}, r.CDN.CDNIPs...),
+		RealIPs: append([]string{}, r.CDN.RealIPs...),
+		Cert:    reportColumns["cert"].value(r),
+		Fingers: []htmlFinger{},
+		Headers: []string{},
+		Ends:    []string{},
+	}
+	for _, e := range r.Endpoints {
+		rec.Ends = append(rec.Ends, e.URL+" ("+e.IP+", "+strconv.Itoa(e.StatusCode)+")")
+	}
+	for _, f := range r.Fingers {
+		rec.Fingers = append(rec.Fingers, htmlFinger{
+			CMS:     f.CMS,
+			Version: f.Version,
+			Level:   f.Level,
+			Tags:    append([]string{}, f.Tags...),
+			Matched: append([]string{}, f.Matched...),
+		})
+	}
+	for k, values := range r.Headers {
+		for _, v := range values {
+			rec.Headers = append(rec.Headers, k+": "+v)
+		}
+	}
+	sort.Strings(rec.Headers)
+	w.records = append(w.records, rec)
+	return nil
+}
+
+// Flush HTML 报告只在 Close 时整体写出
+func (w *HTMLSink) Flush() error {
+	return nil
+}
+
+func (w *HTMLSink) Close() error {
+	data, err := json.Marshal(w.records)
+	if err != nil {
+		return err
+	}
+	if w.records == nil {
+		data = []byte("[]")
+	}
+
+	var buf bytes.Buffer
+	err = htmlReport.Execute(&buf, struct {
+		Start    string
+		Duration string
+		Total    int
+		Data     template.JS
+	}{
+		Start:    w.start.Format("2006-01-02 15:04:05"),
+		Duration: time.Since(w.start).Round(time.Second).String(),
+		Total:    len(w.records),
+		// json.Marshal 已把 < > & 转义为 < 等，可以直接嵌入 script
+		Data: template.JS(data),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(w.path, buf.Bytes(), 0644)
+}
+
+// iconDataURI 把 favicon 内容转为 data URI，不是图片时返回空
+func iconDataURI(icon []byte) string {
+	if len(icon) == 0 {
+		return ""
+	}
+	mime := http.DetectContentType(icon)
+	if !strings.HasPrefix(mime, "image/") {
+		// DetectContentType 不识别 SVG
+		if !bytes.Contains(bytes.ToLower(icon[:min(len(icon), 512)]), []byte("<svg")) {
+			return ""
+		}
+		mime = "image/svg+xml"
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(icon)
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>dfinger 扫描报告 {{.Start}}</title>
+<style>
+body{font-family:-apple-system,"Segoe UI","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222;font-size:13px}
+header{background:#1f2937;color:#fff;padding:14px 20px}
+header h1{margin:0 0 4px;font-size:18px}
+header span{color:#cbd5e1;margin-right:16px}
+.bar{display:flex;gap:12px;align-items:center;padding:10px 20px;background:#fff;border-bottom:1px solid #e5e7eb;position:sticky;top:0;z-index:1}
+.bar input[type=search]{width:320px;padding:5px 8px;border:1px solid #d1d5db;border-radius:4px}
+.bar select{padding:4px}
+#count{color:#6b7280;margin-left:auto}
+table{border-collapse:collapse;width:100%;background:#fff}
+th,td{padding:6px 8px;border-bottom:1px solid #eef0f3;text-align:left;vertical-align:top}
+th{background:#f9fafb;cursor:pointer;user-select:none;white-space:nowrap;position:sticky;top:45px}
+th.asc:after{content:" ▲"}th.desc:after{content:" ▼"}
+tr.row{cursor:pointer}tr.row:hover{background:#f3f4f6}
+tr.group td{background:#e5e7eb;font-weight:bold}
+tr.detail td{background:#fafafa;padding:8px 24px}
+tr.detail pre{margin:4px 0;white-space:pre-wrap;word-break:break-all;font-size:12px}
+img.icon{width:16px;height:16px;vertical-align:middle}
+a{color:#2563eb;text-decoration:none}
+.tag{display:inline-block;padding:1px 6px;margin:1px;border-radius:3px;font-size:12px;color:#fff}
+.l1{background:#16a34a}.l2{background:#ca8a04}.l3,.l4,.l5{background:#dc2626}
+.s2{color:#16a34a}.s3{color:#ca8a04}.s4,.s5{color:#dc2626}
+.muted{color:#9ca3af}
+</style>
+</head>
+<body>
+<header><h1>dfinger 扫描报告</h1><span>开始时间: {{.Start}}</span><span>耗时: {{.Duration}}</span><span>目标数: {{.Total}}</span></header>
+<div class="bar">
+<input type="search" id="filter" placeholder="筛选：地址、标题、指纹、Server、IP…">
+<select id="finger"><option value="">全部指纹</option></select>
+<label><input type="checkbox" id="group"> 按指纹分组</label>
+<span id="count"></span>
+</div>
+<table>
+<thead><tr>
+<th data-key="icon">图标</th><th data-key="url">地址</th><th data-key="status">状态码</th><th data-key="title">标题</th>
+<th data-key="length">长度</th><th data-key="server">Server</th><th data-key="fingers">指纹</th><th data-key="ip">IP</th><th data-key="time">耗时(ms)</th>
+</tr></thead>
+<tbody id="rows"></tbody>
+</table>
+<script type="application/json" id="data">{{.Data}}</script>
+<script>
+(function(){
+var data=JSON.parse(document.getElementById('data').textContent);
+var sortKey='',sortDir=1,open={};
+data.forEach(function(r,i){r.id=i;r.names=r.fingers.map(function(f){return f.cms});});
+
+function esc(s){return String(s==null?'':s).replace(/[&<>"']/g,function(c){return{'&':'&amp;','<':'&lt;','>':'&gt;','"':'&quot;',"'":'&#39;'}[c]})}
+function label(f){return f.cms+(f.version?'/'+f.version:'')}
+function text(r){return [r.url,r.final,r.title,r.server,r.ip,r.hash,r.cert].concat(r.names,r.endpoints).join(' ').toLowerCase()}
+function val(r,k){
+  if(k==='fingers')return r.names.join(',');
+  if(k==='icon')return r.hash;
+  return r[k];
+}
+
+var names={};
+data.forEach(function(r){r.names.forEach(function(n){names[n]=(names[n]||0)+1})});
+var sel=document.getElementById('finger');
+Object.keys(names).sort(function(a,b){return names[b]-names[a]||(a<b?-1:1)}).forEach(function(n){
+  var o=document.createElement('option');o.value=n;o.textContent=n+' ('+names[n]+')';sel.appendChild(o);
+});
+
+function row(r){
+  var fingers=r.fingers.map(function(f){return '<span class="tag l'+f.level+'">'+esc(label(f))+'</span>'}).join('');
+  var h='<tr class="row" data-id="'+r.id+'">'+
+    '<td>'+(r.icon?'<img class="icon" src="'+r.icon+'" title="'+esc(r.hash)+'">':'')+'</td>'+
+    '<td><a href="'+esc(r.url)+'" target="_blank" rel="noreferrer">'+esc(r.url)+'</a>'+(r.endpoints.length>1?' <span class="muted">+'+(r.endpoints.length-1)+'</span>':'')+(r.cdn?' <span class="muted">[CDN'+(r.cdn_provider?' '+esc(r.cdn_provider):'')+']</span>':'')+'</td>'+
+    '<td class="s'+String(r.status).charAt(0)+'">'+r.status+'</td>'+
+    '<td>'+esc(r.title)+'</td><td>'+r.length+'</td><td>'+esc(r.server)+'</td>'+
+    '<td>'+fingers+'</td><td>'+esc(r.ip)+'</td><td>'+r.time+'</td></tr>';
+  if(open[r.id]){
+    var d='';
+    if(r.endpoints.length>1)d+='<div>相同内容的地址: '+r.endpoints.map(esc).join('<br>')+'</div>';
+    if(r.final&&r.final!==r.url)d+='<div>最终地址: '+esc(r.final)+'</div>';
+    if(r.cnames.length)d+='<div>CNAME: '+esc(r.cnames.join(' -> '))+'</div>';
+    if(r.cdn_provider)d+='<div>CDN 厂商: '+esc(r.cdn_provider)+'</div>';
+    if(r.cdn_ips.length)d+='<div>CDN IP: '+esc(r.cdn_ips.join(', '))+'</div>';
+    if(r.real_ips.length)d+='<div>真实 IP: '+esc(r.real_ips.join(', '))+'</div>';
+    if(r.cert)d+='<div>证书: '+esc(r.cert)+'</div>';
+    if(r.hash)d+='<div>图标 Hash: '+esc(r.hash)+'</div>';
+    r.fingers.forEach(function(f){
+      d+='<div><b>'+esc(label(f))+'</b> 等级 '+f.level+(f.tags.length?' 标签: '+esc(f.tags.join(', ')):'')+
+        (f.matched.length?' 匹配: '+esc(f.matched.join(' | ')):'')+'</div>';
+    });
+    d+='<pre>'+esc(r.headers.join('\n'))+'</pre>';
+    h+='<tr class="detail"><td colspan="9">'+d+'</td></tr>';
+  }
+  return h;
+}
+
+function render(){
+  var q=document.getElementById('filter').value.trim().toLowerCase();
+  var f=sel.value;
+  var list=data.filter(function(r){
+    return (!q||text(r).indexOf(q)>=0)&&(!f||r.names.indexOf(f)>=0);
+  });
+  if(sortKey){
+    list.sort(function(a,b){
+      var x=val(a,sortKey),y=val(b,sortKey);
+      if(typeof x==='number')return (x-y)*sortDir;
+      return String(x).localeCompare(String(y))*sortDir;
+    });
+  }
+  var html='';
+  if(document.getElementById('group').checked){
+    var groups={},order=[];
+    list.forEach(function(r){
+      (r.names.length?r.names:['未识别']).forEach(function(n){
+        if(f&&n!==f)return;
+        if(!groups[n]){groups[n]=[];order.push(n)}
+        groups[n].push(r);
+      });
+    });
+    order.sort(function(a,b){return groups[b].length-groups[a].length||(a<b?-1:1)});
+    order.forEach(function(n){
+      html+='<tr class="group"><td colspan="9">'+esc(n)+' ('+groups[n].length+')</td></tr>';
+      groups[n].forEach(function(r){html+=row(r)});
+    });
+  }else{
+    list.forEach(function(r){html+=row(r)});
+  }
+  document.getElementById('rows').innerHTML=html;
+  document.getElementById('count').textContent='显示 '+list.length+' / '+data.length;
+}
+
+document.querySelectorAll('th').forEach(function(th){
+  th.addEventListener('click',function(){
+    var k=th.getAttribute('data-key');
+    sortDir=(sortKey===k)?-sortDir:1;sortKey=k;
+    document.querySelectorAll('th').forEach(function(t){t.className=''});
+    th.className=sortDir>0?'asc':'desc';
+    render();
+  });
+});
+document.getElementById('rows').addEventListener('click',function(e){
+  if(e.target.tagName==='A')return;
+  var tr=e.target.closest('tr.row');
+  if(!tr)return;
+  var id=tr.getAttribute('data-id');
+  open[id]=!open[id];
+  render();
+});
+document.getElementById('filter').addEventListener('input',render);
+sel.addEventListener('change',render);
+document.getElementById('group').addEventListener('change',render);
+render();
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,116 @@
+package finger
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTMLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	w, err := NewHTMLSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	results := []*ScanResult{
+		{
+			URL:           "https://10.0.0.1/",
+			FinalURL:      "https://10.0.0.1/login?next=<x>&a='b'",
+			IP:            "10.0.0.1",
+			Port:          443,
+			StatusCode:    200,
+			Title:         `</script><script>alert("xss")</script><!--`,
+			ContentLength: 1024,
+			ResponseTime:  35,
+			Server:        "nginx & <apache>",
+			Icon:          png,
+			IconHash:      "-1234",
+			CDN:           CDNResult{IsCDN: true, Provider: "cloudflare", CNAMEs: []string{"a.cdn.net"}, CDNIPs: []string{"1.1.1.1"}},
+			Cert:          &CertInfo{Subject: "CN=FortiGate,O=Fortinet", CommonName: "FortiGate", SelfSigned: true},
+			Fingers:       []DetectionResult{{CMS: "Fortinet <FortiGate>", Version: "7.0", Level: 3, Tags: []string{"firewall"}, Matched: []string{`title="a&b"`}}},
+			Headers:       http.Header{"Server": {"nginx"}, "Content-Type": {"text/html"}, "Set-Cookie": {"a=1", "b=2"}},
+			Endpoints:     []Endpoint{{URL: "https://10.0.0.1/", IP: "10.0.0.1", StatusCode: 200}},
+		},
+		{URL: "http://10.0.0.2/", Icon: []byte("not an image")},
+		{URL: "http://10.0.0.3/", Icon: []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+	}
+	for _, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	page, records := readHTMLReport(t, path)
+	// 结果中的 HTML 不能原样出现在页面中
+	for _, raw := range []string{`<script>alert`, `<!--`, "<apache>", "<FortiGate>", "<x>"} {
+		if strings.Contains(page, raw) {
+			t.Errorf("页面中出现未转义的 %q", raw)
+		}
+	}
+	if strings.Count(page, "</script>") != 2 {
+		t.Errorf("页面中 </script> 出现 %d 次，期望 2 次", strings.Count(page, "</script>"))
+	}
+	if !strings.Contains(page, "<span>目标数: 3</span>") {
+		t.Error("页面缺少目标数")
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("报告 %d 条记录，期望 3", len(records))
+	}
+	want := htmlRecord{
+		URL:     "https://10.0.0.1/",
+		Final:   "https://10.0.0.1/login?next=<x>&a='b'",
+		IP:      "10.0.0.1",
+		Port:    443,
+		Status:  200,
+		Title:   `</script><script>alert("xss")</script><!--`,
+		Length:  1024,
+		Time:    35,
+		Server:  "nginx & <apache>",
+		Icon:    "data:image/png;base64,iVBORw0KGgoAAAANSUhEUg==",
+		Hash:    "-1234",
+		CDN:     true,
+		CDNName: "cloudflare",
+		CNAMEs:  []string{"a.cdn.net"},
+		CDNIPs:  []string{"1.1.1.1"},
+		RealIPs: []string{},
+		Cert:    "FortiGate [自签名]",
+		Fingers: []htmlFinger{{CMS: "Fortinet <FortiGate>", Version: "7.0", Level: 3, Tags: []string{"firewall"}, Matched: []string{`title="a&b"`}}},
+		Headers: []string{"Content-Type: text/html", "Server: nginx", "Set-Cookie: a=1", "Set-Cookie: b=2"},
+		Ends:    []string{"https://10.0.0.1/ (10.0.0.1, 200)"},
+	}
+	if !reflect.DeepEqual(records[0], want) {
+		t.Errorf("报告记录\n%+v\n期望\n%+v", records[0], want)
+	}
+	if records[1].Icon != "" {
+		t.Errorf("非图片 favicon 不应展示: %q", records[1].Icon)
+	}
+	if !strings.HasPrefix(records[2].Icon, "data:image/svg+xml;base64,") {
+		t.Errorf("SVG favicon 类型错误: %q", records[2].Icon)
+	}
+	// 没有结果的字段输出空列表，页面脚本不需要判断 null
+	if records[1].Fingers == nil || records[1].Headers == nil || records[1].Ends == nil || records[1].CNAMEs == nil {
+		t.Errorf("空字段应为空列表: %+v", records[1])
+	}
+}
+
+func TestHTMLSinkEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	w, err := NewHTMLSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	page, records := readHTMLReport(t, path)
+	if len(records) != 0 || !strings.Contains(page, `id="data">[]</script>`) {
+		t.Fatalf("没有结果时数据应为 []: %d 条", len(records))
+	}
+}
@@ -0,0 +1,125 @@
+package finger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 指纹库格式
+const (
+	FormatDfinger    = "dfinger"    // 本项目的格式，JSON 或 YAML
+	FormatEHole      = "ehole"      // EHole finger.json
+	FormatHub        = "hub"        // FingerprintHub web-fingerprint YAML
+	FormatWappalyzer = "wappalyzer" // Wappalyzer technologies/*.json
+)
+
+// ImportIssue 导入第三方指纹时无法转换的条件
+type ImportIssue struct {
+	Source string // 来源文件
+	Rule   string // 规则名
+	Reason string // 原因
+}
+
+func (i ImportIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Source, i.Rule, i.Reason)
+}
+
+// issueCollector 收集单个文件导入过程中的问题
+type issueCollector struct {
+	source string
+	issues []ImportIssue
+}
+
+func (c *issueCollector) add(rule string, format string, args ...any) {
+	c.issues = append(c.issues, ImportIssue{Source: c.source, Rule: rule, Reason: fmt.Sprintf(format, args...)})
+}
+
+// DetectFormat 根据扩展名和内容判断指纹库格式
+func DetectFormat(path string, data []byte) string {
+	if isYAML(path) {
+		// 顶层为规则列表的是 dfinger 规则，FingerprintHub 模板顶层是带 id/info/http 的对象
+		var top yaml.Node
+		if err := yaml.Unmarshal(data, &top); err == nil && len(top.Content) > 0 && top.Content[0].Kind == yaml.SequenceNode {
+			return FormatDfinger
+		}
+		return FormatHub
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '[' {
+		return FormatDfinger
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &top); err == nil {
+		if _, ok := top["fingerprint"]; ok {
+			return FormatEHole
+		}
+		return FormatWappalyzer
+	}
+	return FormatDfinger
+}
+
+// ParseRules 解析指纹库文件内容，format 为空时自动识别，
+// 第三方格式中无法转换的条件通过 ImportIssue 逐条返回
+func ParseRules(path string, data []byte, format string) ([]FingerprintRule, []ImportIssue, error) {
+	if format == "" {
+		format = DetectFormat(path, data)
+	}
+
+	c := &issueCollector{source: path}
+	var (
+		rules []FingerprintRule
+		err   error
+	)
+	switch format {
+	case FormatDfinger:
+		if isYAML(path) {
+			err = yaml.Unmarshal(data, &rules)
+		} else {
+			err = json.Unmarshal(data, &rules)
+		}
+	case FormatEHole:
+		rules, err = importEHole(data, c)
+	case FormatHub:
+		rules, err = importHub(data, c)
+	case FormatWappalyzer:
+		rules, err = importWappalyzer(data, c)
+	default:
+		err = fmt.Errorf("未知的指纹库格式: %q", format)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, c.issues, nil
+}
+
+// isYAML 按扩展名判断是否为 YAML 文件
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// exactHashes 把 favicon hash 转换为整串匹配的正则，避免 match 子串匹配时
+// 116323821 命中 -116323821、短 hash 命中长 hash
+func exactHashes(hashes []string) []string {
+	exprs := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		exprs = append(exprs, "^"+regexp.QuoteMeta(strings.TrimSpace(h))+"$")
+	}
+	return exprs
+}
+
+// checkRegex 校验第三方规则中的正则能否被 Go 正则引擎编译（不支持前瞻、反向引用等）
+func checkRegex(expr string) error {
+	_, err := regexp.Compile("(?i)" + expr)
+	return err
+}
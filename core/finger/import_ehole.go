@@ -0,0 +1,94 @@
+package finger
+
+import (
+	"encoding/json"
+)
+
+// EHole finger.json 格式
+type eholeFile struct {
+	Fingerprint []eholeRule `json:"fingerprint"`
+}
+
+type eholeRule struct {
+	CMS      string   `json:"cms"`
+	Method   string   `json:"method"`   // keyword, faviconhash, regular
+	Location string   `json:"location"` // body, header, title
+	Keyword  []string `json:"keyword"`
+}
+
+// importEHole 转换 EHole 指纹，每条 EHole 指纹对应一条规则，关键字为 and 关系
+func importEHole(data []byte, c *issueCollector) ([]FingerprintRule, error) {
+	var f eholeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var rules []FingerprintRule
+	for _, er := range f.Fingerprint {
+		cond := Condition{Location: er.Location, Keywords: er.Keyword}
+		switch er.Location {
+		case "body", "header", "title":
+		default:
+			c.add(er.CMS, "不支持的位置 %q", er.Location)
+			continue
+		}
+
+		switch er.Method {
+		case "keyword":
+			cond.Matcher = "match"
+		case "regular":
+			cond.Matcher = "regex"
+			if !validRegexes(er.CMS, er.Keyword, c) {
+				continue
+			}
+		case "faviconhash":
+			// EHole 的 faviconhash 整串比较，命中任意一个即可
+			cond = Condition{Location: "favicon", Matcher: "regex", Keywords: exactHashes(er.Keyword)}
+			if len(er.Keyword) > 1 {
+				rules = append(rules, FingerprintRule{
+					CMS:        er.CMS,
+					Level:      3,
+					Logic:      "or",
+					Tags:       []string{er.CMS},
+					Conditions: splitKeywords(cond),
+				})
+				continue
+			}
+		default:
+			c.add(er.CMS, "不支持的匹配方式 %q", er.Method)
+			continue
+		}
+
+		rules = append(rules, FingerprintRule{
+			CMS:        er.CMS,
+			Level:      3,
+			Logic:      "and",
+			Tags:       []string{er.CMS},
+			Conditions: []Condition{cond},
+		})
+	}
+	return rules, nil
+}
+
+// splitKeywords 把一个条件拆成每个关键字一个条件，用于表达关键字之间的 or 关系
+func splitKeywords(cond Condition) []Condition {
+	conds := make([]Condition, 0, len(cond.Keywords))
+	for _, kw := range cond.Keywords {
+		c := cond
+		c.Keywords = []string{kw}
+		conds = append(conds, c)
+	}
+	return conds
+}
+
+// validRegexes 校验一组正则，无法编译时记录问题
+func validRegexes(rule string, exprs []string, c *issueCollector) bool {
+	ok := true
+	for _, expr := range exprs {
+		if err := checkRegex(expr); err != nil {
+			c.add(rule, "正则无法转换 %q: %v", expr, err)
+			ok = false
+		}
+	}
+	return ok
+}
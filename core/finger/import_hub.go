@@ -0,0 +1,256 @@
+package finger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FingerprintHub web-fingerprint 模板（nuclei 模板格式）
+type hubTemplate struct {
+	ID   string `yaml:"id"`
+	Info struct {
+		Name string `yaml:"name"`
+	} `yaml:"info"`
+	HTTP     []hubRequest `yaml:"http"`
+	Requests []hubRequest `yaml:"requests"` // 旧版 nuclei 字段名
+}
+
+type hubRequest struct {
+	Method            string            `yaml:"method"`
+	Path              []string          `yaml:"path"`
+	Raw               []string          `yaml:"raw"`
+	Headers           map[string]string `yaml:"headers"`
+	Body              string            `yaml:"body"`
+	MatchersCondition string            `yaml:"matchers-condition"`
+	Matchers          []hubMatcher      `yaml:"matchers"`
+	Extractors        []hubExtractor    `yaml:"extractors"`
+}
+
+type hubMatcher struct {
+	Type      string   `yaml:"type"`
+	Part      string   `yaml:"part"`
+	Condition string   `yaml:"condition"`
+	Negative  bool     `yaml:"negative"`
+	Words     []string `yaml:"words"`
+	Regex     []string `yaml:"regex"`
+	Status    []int    `yaml:"status"`
+	Hash      []string `yaml:"hash"`
+}
+
+type hubExtractor struct {
+	Type  string   `yaml:"type"`
+	Part  string   `yaml:"part"`
+	Regex []string `yaml:"regex"`
+	Group int      `yaml:"group"`
+}
+
+// importHub 转换 FingerprintHub 模板，支持一个文件中包含多个 YAML 文档
+// 每个请求路径生成一条规则，非首页路径转换为主动探测
+func importHub(data []byte, c *issueCollector) ([]FingerprintRule, error) {
+	var rules []FingerprintRule
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var t hubTemplate
+		err := dec.Decode(&t)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, convertHubTemplate(&t, c)...)
+	}
+	return rules, nil
+}
+
+func convertHubTemplate(t *hubTemplate, c *issueCollector) []FingerprintRule {
+	name := t.Info.Name
+	if name == "" {
+		name = t.ID
+	}
+
+	var rules []FingerprintRule
+	for _, req := range append(t.HTTP, t.Requests...) {
+		if len(req.Raw) > 0 {
+			c.add(name, "不支持 raw 请求")
+			continue
+		}
+
+		conds, logic, ok := convertHubMatchers(name, req, c)
+		if !ok {
+			continue
+		}
+		extractors := convertHubExtractors(name, req.Extractors, c)
+
+		paths := req.Path
+		if len(paths) == 0 {
+			paths = []string{"{{BaseURL}}/"}
+		}
+		for _, p := range paths {
+			probe, ok := convertHubPath(name, p, req, c)
+			if !ok {
+				continue
+			}
+			rules = append(rules, FingerprintRule{
+				CMS:        name,
+				Level:      3,
+				Logic:      logic,
+				Tags:       []string{t.ID},
+				Conditions: conds,
+				Extractors: extractors,
+				Probe:      probe,
+			})
+		}
+	}
+	return rules
+}
+
+// convertHubPath 首页路径返回 nil，其余路径转换为主动探测
+func convertHubPath(name string, path string, req hubRequest, c *issueCollector) (*Probe, bool) {
+	p, ok := strings.CutPrefix(path, "{{BaseURL}}")
+	if !ok || strings.Contains(p, "{{") {
+		c.add(name, "不支持的请求路径 %q", path)
+		return nil, false
+	}
+	method := strings.ToUpper(req.Method)
+	if (p == "" || p == "/") && (method == "" || method == "GET") && len(req.Headers) == 0 && req.Body == "" {
+		return nil, true
+	}
+	if p == "" {
+		p = "/"
+	}
+	return &Probe{Method: method, Path: p, Headers: req.Headers, Body: req.Body}, true
+}
+
+func convertHubMatchers(name string, req hubRequest, c *issueCollector) ([]Condition, string, bool) {
+	// nuclei 中 matchers-condition 默认为 or
+	logic := strings.ToLower(req.MatchersCondition)
+	if logic == "" {
+		logic = "or"
+	}
+
+	var conds []Condition
+	for i, m := range req.Matchers {
+		cond, err := convertHubMatcher(m)
+		if err != nil {
+			c.add(name, "matcher%d: %v", i, err)
+			if logic == "and" {
+				// and 关系下丢掉任何一个条件都会导致误报
+				return nil, "", false
+			}
+			continue
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		c.add(name, "没有可转换的 matcher")
+		return nil, "", false
+	}
+	return conds, logic, true
+}
+
+func convertHubMatcher(m hubMatcher) (Condition, error) {
+	// nuclei 中 matcher 内部 condition 默认为 or
+	logic := strings.ToLower(m.Condition)
+	if logic == "" {
+		logic = "or"
+	}
+
+	var base Condition
+	var keywords []string
+	switch m.Type {
+	case "word":
+		base.Matcher = "match"
+		keywords = m.Words
+	case "regex":
+		base.Matcher = "regex"
+		keywords = m.Regex
+		for _, expr := range m.Regex {
+			if err := checkRegex(expr); err != nil {
+				return Condition{}, fmt.Errorf("正则无法转换 %q: %v", expr, err)
+			}
+		}
+	case "status":
+		base = Condition{Location: "status", Matcher: "match"}
+		for _, s := range m.Status {
+			keywords = append(keywords, strconv.Itoa(s))
+		}
+	case "favicon":
+		// favicon hash 整串比较
+		base = Condition{Location: "favicon", Matcher: "regex"}
+		keywords = exactHashes(m.Hash)
+	default:
+		return Condition{}, fmt.Errorf("不支持的 matcher 类型 %q", m.Type)
+	}
+	if len(keywords) == 0 {
+		return Condition{}, fmt.Errorf("matcher 没有关键字")
+	}
+
+	var parts []string
+	if base.Location == "" {
+		switch m.Part {
+		case "", "body":
+			parts = []string{"body"}
+		case "header":
+			parts = []string{"header"}
+		case "response", "all":
+			parts = []string{"body", "header"}
+		default:
+			return Condition{}, fmt.Errorf("不支持的 part %q", m.Part)
+		}
+	} else {
+		parts = []string{base.Location}
+	}
+
+	// 每个位置生成一个条件，or 关系时按关键字拆分
+	var conds []Condition
+	for _, part := range parts {
+		cond := Condition{Location: part, Matcher: base.Matcher, Keywords: keywords}
+		if logic == "or" && len(keywords) > 1 {
+			conds = append(conds, splitKeywords(cond)...)
+		} else {
+			conds = append(conds, cond)
+		}
+	}
+
+	var cond Condition
+	if len(conds) == 1 {
+		cond = conds[0]
+	} else {
+		cond = Condition{Logic: "or", Conditions: conds}
+	}
+	cond.Not = m.Negative
+	return cond, nil
+}
+
+func convertHubExtractors(name string, extractors []hubExtractor, c *issueCollector) []Extractor {
+	var result []Extractor
+	for i, ex := range extractors {
+		if ex.Type != "regex" {
+			c.add(name, "extractor%d: 不支持的 extractor 类型 %q", i, ex.Type)
+			continue
+		}
+		loc := "body"
+		switch ex.Part {
+		case "", "body":
+		case "header":
+			loc = "header"
+		default:
+			c.add(name, "extractor%d: 不支持的 part %q", i, ex.Part)
+			continue
+		}
+		for _, expr := range ex.Regex {
+			if err := checkRegex(expr); err != nil {
+				c.add(name, "extractor%d: 正则无法转换 %q: %v", i, expr, err)
+				continue
+			}
+			result = append(result, Extractor{Location: loc, Regex: expr, Group: strconv.Itoa(ex.Group)})
+		}
+	}
+	return result
+}
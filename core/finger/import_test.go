@@ -0,0 +1,284 @@
+package finger
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// importInput 导入测试使用的响应
+func importInput(faviconHash string) *ResponseData {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Server":       {"nginx/1.20.1"},
+			"X-Powered-By": {"PHP/7.4.3"},
+			"Set-Cookie":   {"other=PHPSESSID=1", "PHPSESSID=abc123; Path=/", "laravel_session=xyz"},
+		},
+	}
+	body := `<html><head><title>Admin</title>` +
+		`<meta name="generator" content="WordPress 6.4.2">` +
+		`<script src="https://cdn.example.com/jquery-3.6.0.min.js"></script>` +
+		`<script src="/static/app.js?v=jquery-1.0.0.min.js"></script>` +
+		`</head><body>Powered by Acme</body></html>`
+	return &ResponseData{Resp: resp, Body: body, Title: "Admin", FaviconHash: faviconHash, Path: "/"}
+}
+
+// importDetect 导入并编译指纹，返回命中的 CMS（按名称排序）和版本
+func importDetect(t *testing.T, path, data string, input *ResponseData) (map[string]string, []ImportIssue) {
+	t.Helper()
+	rules, issues, err := ParseRules(path, []byte(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := CompileRules(rules, path)
+	if err != nil {
+		t.Fatalf("转换后的规则无法编译: %v", err)
+	}
+	hits := make(map[string]string)
+	for _, r := range rs.Detect(input) {
+		hits[r.CMS] = r.Version
+	}
+	return hits, issues
+}
+
+func hitNames(hits map[string]string) []string {
+	names := make([]string, 0, len(hits))
+	for name := range hits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func issueReasons(issues []ImportIssue) []string {
+	var reasons []string
+	for _, i := range issues {
+		reasons = append(reasons, i.Rule+": "+i.Reason)
+	}
+	return reasons
+}
+
+func TestImportEHole(t *testing.T) {
+	data := `{"fingerprint":[
+		{"cms":"nginx","method":"keyword","location":"header","keyword":["nginx"]},
+		{"cms":"acme","method":"keyword","location":"body","keyword":["Powered by","Acme"]},
+		{"cms":"acme-missing","method":"keyword","location":"body","keyword":["Powered by","Other"]},
+		{"cms":"admin","method":"regular","location":"title","keyword":["^adm"]},
+		{"cms":"icon","method":"faviconhash","location":"body","keyword":["116323821"]},
+		{"cms":"icon-negative","method":"faviconhash","location":"body","keyword":["-116323821"]},
+		{"cms":"icon-short","method":"faviconhash","location":"body","keyword":["11632382"]},
+		{"cms":"icon-any","method":"faviconhash","location":"body","keyword":["1","116323821"]},
+		{"cms":"bad-location","method":"keyword","location":"cookie","keyword":["a"]},
+		{"cms":"bad-method","method":"icon","location":"body","keyword":["a"]},
+		{"cms":"bad-regex","method":"regular","location":"body","keyword":["(?=x)"]}
+	]}`
+	if got := DetectFormat("finger.json", []byte(data)); got != FormatEHole {
+		t.Fatalf("格式识别为 %s", got)
+	}
+
+	hits, issues := importDetect(t, "finger.json", data, importInput("116323821"))
+	if want := []string{"acme", "admin", "icon", "icon-any", "nginx"}; !reflect.DeepEqual(hitNames(hits), want) {
+		t.Errorf("命中 %v，期望 %v", hitNames(hits), want)
+	}
+	if len(issues) != 3 {
+		t.Errorf("转换问题 %v，期望 3 个", issueReasons(issues))
+	}
+
+	// favicon hash 整串比较，正负号不同或是子串都不算命中
+	hits, _ = importDetect(t, "finger.json", data, importInput("-116323821"))
+	if !hasKey(hits, "icon-negative") || hasKey(hits, "icon") || hasKey(hits, "icon-short") || hasKey(hits, "icon-any") {
+		t.Errorf("favicon hash 应整串比较，命中 %v", hitNames(hits))
+	}
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func TestImportHub(t *testing.T) {
+	data := `id: acme-panel
+info:
+  name: Acme Panel
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/"
+    matchers-condition: and
+    matchers:
+      - type: word
+        part: header
+        words:
+          - nginx
+      - type: regex
+        regex:
+          - "powered by (acme|other)"
+      - type: status
+        status:
+          - 200
+      - type: word
+        negative: true
+        words:
+          - wp-content
+    extractors:
+      - type: regex
+        part: header
+        regex:
+          - "nginx/([\\d.]+)"
+        group: 1
+---
+id: acme-icon
+info:
+  name: Acme Icon
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/favicon.ico"
+    matchers:
+      - type: favicon
+        hash:
+          - "116323821"
+          - "-999"
+---
+id: home-icon
+info:
+  name: Home Icon
+http:
+  - path:
+      - "{{BaseURL}}"
+    matchers:
+      - type: favicon
+        hash:
+          - "116323821"
+---
+id: words-and
+info:
+  name: Words And
+http:
+  - path:
+      - "{{BaseURL}}/"
+    matchers:
+      - type: word
+        condition: and
+        words:
+          - Acme
+          - missing
+---
+id: unsupported
+info:
+  name: Unsupported
+http:
+  - raw:
+      - "GET / HTTP/1.1"
+  - path:
+      - "{{BaseURL}}/"
+    matchers-condition: and
+    matchers:
+      - type: dsl
+        dsl:
+          - "true"
+      - type: word
+        words:
+          - Acme
+`
+	if got := DetectFormat("acme.yaml", []byte(data)); got != FormatHub {
+		t.Fatalf("格式识别为 %s", got)
+	}
+	rules, issues, err := ParseRules("acme.yaml", []byte(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Unsupported: 不支持 raw 请求", `Unsupported: matcher0: 不支持的 matcher 类型 "dsl"`}; !reflect.DeepEqual(issueReasons(issues), want) {
+		t.Errorf("转换问题 %q，期望 %q", issueReasons(issues), want)
+	}
+
+	var probes []string
+	for _, r := range rules {
+		if r.Probe != nil {
+			probes = append(probes, r.CMS+" "+r.Probe.Method+" "+r.Probe.Path)
+		}
+	}
+	if want := []string{"Acme Icon GET /favicon.ico"}; !reflect.DeepEqual(probes, want) {
+		t.Errorf("主动探测 %v，期望 %v", probes, want)
+	}
+
+	hits, _ := importDetect(t, "acme.yaml", data, importInput("116323821"))
+	if want := []string{"Acme Panel", "Home Icon"}; !reflect.DeepEqual(hitNames(hits), want) {
+		t.Errorf("命中 %v，期望 %v", hitNames(hits), want)
+	}
+	if hits["Acme Panel"] != "1.20.1" {
+		t.Errorf("版本 %q，期望 1.20.1", hits["Acme Panel"])
+	}
+
+	hits, _ = importDetect(t, "acme.yaml", data, importInput("-116323821"))
+	if hasKey(hits, "Home Icon") {
+		t.Errorf("favicon hash 应整串比较，-116323821 不应命中 116323821")
+	}
+}
+
+func TestImportWappalyzer(t *testing.T) {
+	data := `{
+		"PHP": {"cats": [27], "headers": {"X-Powered-By": "^php/?([\\d.]+)?\\;version:\\1"}, "cookies": {"PHPSESSID": ""}},
+		"Laravel": {"cookies": {"laravel_session": ""}},
+		"Session": {"cookies": {"PHPSESSID": "^abc(\\d+)$\\;version:\\1"}},
+		"Session Prefix": {"cookies": {"PHPSESSID": "^bc"}},
+		"Cookie In Value": {"cookies": {"PHPSESSID": "=1"}},
+		"jQuery": {"scriptSrc": ["^https?://cdn\\.example\\.com/jquery-([\\d.]+)\\.min\\.js$\\;version:\\1"]},
+		"jQuery Local": {"scriptSrc": "^/static/jquery"},
+		"App Script": {"scriptSrc": "app\\.js"},
+		"WordPress": {"meta": {"generator": "^wordpress ?([\\d.]+)?\\;version:\\1"}, "js": {"wp": ""}},
+		"Nginx": {"headers": {"Server": "nginx(?:/([\\d.]+))?\\;version:\\1"}},
+		"Server Header": {"headers": {"Server": ""}},
+		"Lookahead": {"html": "(?=x)"}
+	}`
+	if got := DetectFormat("technologies.json", []byte(data)); got != FormatWappalyzer {
+		t.Fatalf("格式识别为 %s", got)
+	}
+
+	hits, issues := importDetect(t, "technologies.json", data, importInput(""))
+	want := map[string]string{
+		"PHP":           "7.4.3",
+		"Laravel":       "",
+		"Session":       "123",
+		"jQuery":        "3.6.0",
+		"App Script":    "",
+		"WordPress":     "6.4.2",
+		"Nginx":         "1.20.1",
+		"Server Header": "",
+	}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("命中 %v，期望 %v", hits, want)
+	}
+
+	reasons := strings.Join(issueReasons(issues), "\n")
+	for _, s := range []string{`WordPress: 不支持的字段 "js"`, "Lookahead: html: 正则无法转换", "Lookahead: 没有可转换的条件"} {
+		if !strings.Contains(reasons, s) {
+			t.Errorf("转换问题中缺少 %q:\n%s", s, reasons)
+		}
+	}
+}
+
+func TestWappalyzerPatternAnchoring(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"cookie 无值", wappalyzerCookie("PHPSESSID", ""), `(?m)^PHPSESSID=`},
+		{"cookie 锚定值开头", wappalyzerCookie("sess.id", `^abc(\d)\;version:\1`), `(?m)^sess\.id=(?:abc(\d))\;version:\1`},
+		{"cookie 未锚定", wappalyzerCookie("sid", `a|b`), `(?m)^sid=.*?(?:a|b)`},
+		{"scriptSrc 锚定开头和结尾", wappalyzerScriptSrc(`^https?://x\.com/a\.js$`), `src=["']?https?://x\.com/a\.js["'\s>]`},
+		{"scriptSrc 转义的 $ 保留", wappalyzerScriptSrc(`^/a\$`), `src=["']?/a\$`},
+		{"scriptSrc 未锚定", wappalyzerScriptSrc(`jquery-([\d.]+)\.js\;version:\1`), `jquery-([\d.]+)\.js\;version:\1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("转换为 %q，期望 %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package finger
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Wappalyzer 中不参与识别的元数据字段
+var wappalyzerMetaFields = map[string]bool{
+	"cats": true, "website": true, "description": true, "icon": true, "cpe": true,
+	"saas": true, "oss": true, "pricing": true, "implies": true, "requires": true,
+	"requiresCategory": true, "excludes": true,
+}
+
+var wappalyzerVersionRe = regexp.MustCompile(`^\\(\d)$`)
+
+// importWappalyzer 转换 Wappalyzer technologies/*.json，每个技术生成一条 or 规则
+func importWappalyzer(data []byte, c *issueCollector) ([]FingerprintRule, error) {
+	var techs map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &techs); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(techs))
+	for name := range techs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []FingerprintRule
+	for _, name := range names {
+		if rule, ok := convertWappalyzerTech(name, techs[name], c); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func convertWappalyzerTech(name string, fields map[string]json.RawMessage, c *issueCollector) (FingerprintRule, bool) {
+	rule := FingerprintRule{
+		CMS:   name,
+		Level: 3,
+		Logic: "or",
+		Tags:  []string{strings.ToLower(name)},
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if wappalyzerMetaFields[key] {
+			continue
+		}
+		raw := fields[key]
+		switch key {
+		case "html", "text":
+			for _, p := range wappalyzerStrings(raw) {
+				addWappalyzerPattern(&rule, name, key, "body", p, c)
+			}
+		case "scriptSrc":
+			// 脚本地址出现在响应体的 src 属性中
+			for _, p := range wappalyzerStrings(raw) {
+				addWappalyzerPattern(&rule, name, key, "body", wappalyzerScriptSrc(p), c)
+			}
+		case "headers":
+			headers, patterns := wappalyzerMap(raw)
+			for _, header := range headers {
+				h := http.CanonicalHeaderKey(header)
+				for _, p := range patterns[header] {
+					if p == "" {
+						addWappalyzerPattern(&rule, name, key, "header", `(?m)^`+regexp.QuoteMeta(h)+`:`, c)
+						continue
+					}
+					addWappalyzerPattern(&rule, name, key, "header:"+h, p, c)
+				}
+			}
+		case "cookies":
+			cookies, patterns := wappalyzerMap(raw)
+			for _, ck := range cookies {
+				for _, p := range patterns[ck] {
+					addWappalyzerPattern(&rule, name, key, "cookie", wappalyzerCookie(ck, p), c)
+				}
+			}
+		case "meta":
+			metas, patterns := wappalyzerMap(raw)
+			for _, m := range metas {
+				for _, p := range patterns[m] {
+					addWappalyzerPattern(&rule, name, key, "body", wappalyzerMeta(m, p), c)
+				}
+			}
+		default:
+			c.add(name, "不支持的字段 %q", key)
+		}
+	}
+
+	if len(rule.Conditions) == 0 {
+		c.add(name, "没有可转换的条件")
+		return rule, false
+	}
+	return rule, true
+}
+
+// addWappalyzerPattern 转换一条 Wappalyzer 模式，格式为 正则\;version:\1\;confidence:50
+func addWappalyzerPattern(rule *FingerprintRule, name, field, location, pattern string, c *issueCollector) {
+	parts := strings.Split(pattern, `\;`)
+	expr := parts[0]
+
+	if expr == "" {
+		c.add(name, "%s: 空模式", field)
+		return
+	}
+	if err := checkRegex(expr); err != nil {
+		c.add(name, "%s: 正则无法转换 %q: %v", field, expr, err)
+		return
+	}
+
+	cond := Condition{Location: location, Matcher: "regex", Keywords: []string{expr}}
+	rule.Conditions = append(rule.Conditions, cond)
+
+	for _, attr := range parts[1:] {
+		v, ok := strings.CutPrefix(attr, "version:")
+		if !ok {
+			continue
+		}
+		m := wappalyzerVersionRe.FindStringSubmatch(v)
+		if m == nil || regexp.MustCompile("(?i)"+expr).NumSubexp() < int(m[1][0]-'0') {
+			c.add(name, "%s: 不支持的版本表达式 %q", field, v)
+			continue
+		}
+		rule.Extractors = append(rule.Extractors, Extractor{Location: location, Regex: expr, Group: m[1]})
+	}
+}
+
+// wappalyzerCookie 把 cookie 模式转换为针对 name=value 行的单个正则，模式中的 ^ 锚定到 cookie 值的开头
+func wappalyzerCookie(name string, pattern string) string {
+	expr, attrs, _ := strings.Cut(pattern, `\;`)
+	if attrs != "" {
+		attrs = `\;` + attrs
+	}
+	prefix := `(?m)^` + regexp.QuoteMeta(name) + `=`
+	switch {
+	case expr == "":
+	case strings.HasPrefix(expr, "^"):
+		expr = `(?:` + expr[1:] + `)`
+	default:
+		expr = `.*?(?:` + expr + `)`
+	}
+	return prefix + expr + attrs
+}
+
+// wappalyzerScriptSrc 把脚本地址模式转换为针对响应体的正则，模式中的 ^ $ 锚定到 src 属性值
+func wappalyzerScriptSrc(pattern string) string {
+	expr, attrs, _ := strings.Cut(pattern, `\;`)
+	if attrs != "" {
+		attrs = `\;` + attrs
+	}
+	if trimmed, ok := strings.CutPrefix(expr, "^"); ok {
+		expr = `src=["']?` + trimmed
+	}
+	if trimmed, ok := strings.CutSuffix(expr, "$"); ok && !strings.HasSuffix(trimmed, `\`) {
+		expr = trimmed + `["'\s>]`
+	}
+	return expr + attrs
+}
+
+// wappalyzerMeta 把 meta 模式转换为针对响应体的正则，模式中的 ^ $ 锚定到 content 属性值
+func wappalyzerMeta(name string, pattern string) string {
+	expr, attrs, _ := strings.Cut(pattern, `\;`)
+	if attrs != "" {
+		attrs = `\;` + attrs
+	}
+	prefix := `<meta[^>]+(?:name|property)=["']?` + regexp.QuoteMeta(name) + `["']?[^>]*?content=["']`
+	if trimmed, ok := strings.CutPrefix(expr, "^"); ok {
+		expr = trimmed
+	} else {
+		expr = `[^"']*?` + expr
+	}
+	if trimmed, ok := strings.CutSuffix(expr, "$"); ok && !strings.HasSuffix(trimmed, `\`) {
+		expr = trimmed + `["']`
+	}
+	return prefix + expr + attrs
+}
+
+// wappalyzerStrings 解析字符串或字符串数组
+func wappalyzerStrings(raw json.RawMessage) []string {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+	return nil
+}
+
+// wappalyzerMap 解析 名称 -> 模式 的对象，值可以是字符串或数组，返回按名称排序的键
+func wappalyzerMap(raw json.RawMessage) ([]string, map[string][]string) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(obj))
+	result := make(map[string][]string, len(obj))
+	for k, v := range obj {
+		keys = append(keys, k)
+		if values := wappalyzerStrings(v); len(values) > 0 {
+			result[k] = values
+		} else {
+			result[k] = []string{""}
+		}
+	}
+	sort.Strings(keys)
+	return keys, result
+}
@@ -0,0 +1,145 @@
+package finger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintIssue 指纹库检查发现的问题
+type LintIssue struct {
+	Source  string
+	Index   int
+	CMS     string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: 第%d条规则(%s): %s", i.Source, i.Index, i.CMS, i.Message)
+}
+
+// LintRules 检查规则中会导致规则静默失效或重复命中的问题：
+// 编译错误（无效正则、未知位置、未知匹配方式和逻辑）、等级越界、空关键字、重复的 CMS 名和完全相同的条件
+func LintRules(rules []FingerprintRule, source string) []LintIssue {
+	var issues []LintIssue
+	add := func(i int, format string, args ...any) {
+		issues = append(issues, LintIssue{Source: source, Index: i, CMS: rules[i].CMS, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 编译错误，逐条规则逐个问题展开
+	if _, err := CompileRules(rules, source); err != nil {
+		for _, e := range flattenErrors(err) {
+			var re *RuleError
+			if !errors.As(e, &re) {
+				issues = append(issues, LintIssue{Source: source, Index: -1, Message: e.Error()})
+				continue
+			}
+			for _, inner := range re.Errs {
+				add(re.Index, "%v", inner)
+			}
+		}
+	}
+
+	cmsIndex := make(map[string]int)
+	condIndex := make(map[string]int)
+	for i, rule := range rules {
+		if rule.Level < 1 || rule.Level > 5 {
+			add(i, "等级 %d 超出范围 1-5", rule.Level)
+		}
+		if strings.TrimSpace(rule.CMS) == "" {
+			add(i, "CMS 名为空")
+		}
+		if len(rule.Conditions) == 0 {
+			add(i, "没有任何条件")
+		}
+		for _, path := range emptyKeywordConditions(rule.Conditions, "") {
+			add(i, "%s: 关键字列表为空", path)
+		}
+
+		if first, ok := cmsIndex[rule.CMS]; ok {
+			add(i, "CMS 名与第%d条规则重复", first)
+		} else {
+			cmsIndex[rule.CMS] = i
+		}
+
+		key := conditionsKey(rule)
+		if first, ok := condIndex[key]; ok {
+			add(i, "条件与第%d条规则(%s)完全相同", first, rules[first].CMS)
+		} else {
+			condIndex[key] = i
+		}
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool { return issues[a].Index < issues[b].Index })
+	return issues
+}
+
+// LintRuleFiles 检查多个文件合并后跨文件重复的 CMS 名和完全相同的条件，
+// 同一文件内的重复由 LintRules 报告，这里只报告与之前文件重复的规则
+func LintRuleFiles(files []RuleFile) []LintIssue {
+	type ref struct {
+		file  int
+		index int
+	}
+	var issues []LintIssue
+	cmsIndex := make(map[string]ref)
+	condIndex := make(map[string]ref)
+	for fi, f := range files {
+		for i, rule := range f.Rules {
+			if first, ok := cmsIndex[rule.CMS]; ok && first.file != fi {
+				issues = append(issues, LintIssue{Source: f.Path, Index: i, CMS: rule.CMS,
+					Message: fmt.Sprintf("CMS 名与 %s 第%d条规则重复", files[first.file].Path, first.index)})
+			} else if !ok {
+				cmsIndex[rule.CMS] = ref{fi, i}
+			}
+
+			key := conditionsKey(rule)
+			if first, ok := condIndex[key]; ok && first.file != fi {
+				issues = append(issues, LintIssue{Source: f.Path, Index: i, CMS: rule.CMS,
+					Message: fmt.Sprintf("条件与 %s 第%d条规则(%s)完全相同", files[first.file].Path, first.index, files[first.file].Rules[first.index].CMS)})
+			} else if !ok {
+				condIndex[key] = ref{fi, i}
+			}
+		}
+	}
+	return issues
+}
+
+// emptyKeywordConditions 返回关键字列表为空的叶子条件路径
+func emptyKeywordConditions(conds []Condition, prefix string) []string {
+	var paths []string
+	for j, cond := range conds {
+		path := fmt.Sprintf("%s条件%d", prefix, j)
+		if len(cond.Conditions) > 0 {
+			paths = append(paths, emptyKeywordConditions(cond.Conditions, path+": ")...)
+			continue
+		}
+		if len(cond.Keywords) == 0 {
+			paths = append(paths, path)
+			continue
+		}
+		for _, kw := range cond.Keywords {
+			if kw == "" {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// conditionsKey 规则匹配逻辑的规范化表示，用于查找条件完全相同的规则
+func conditionsKey(rule FingerprintRule) string {
+	key := struct {
+		Logic      string
+		Conditions []Condition
+		Probe      *Probe
+	}{strings.ToLower(rule.Logic), rule.Conditions, rule.Probe}
+	if key.Logic == "" {
+		key.Logic = "and"
+	}
+	data, _ := json.Marshal(key)
+	return string(data)
+}
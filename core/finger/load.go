@@ -0,0 +1,164 @@
+package finger
+
+import (
+	"dfinger/resource"
+	"errors"
+	"fmt"
+	"github.com/projectdiscovery/gologger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EmbeddedSource 内置指纹库在错误信息中的来源名
+const EmbeddedSource = "embedded:fingers.json"
+
+// RuleFile 单个指纹文件中的规则
+type RuleFile struct {
+	Path  string
+	Rules []FingerprintRule
+}
+
+// ruleExts 目录中会被加载的指纹文件扩展名
+var ruleExts = map[string]bool{".json": true, ".yaml": true, ".yml": true}
+
+// ExpandRulePaths 展开 -finger 参数，支持逗号分隔的文件、目录（递归）和通配符
+func ExpandRulePaths(spec string) ([]string, error) {
+	var paths []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		matches := []string{item}
+		if strings.ContainsAny(item, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(item); err != nil {
+				return nil, fmt.Errorf("无效的通配符 %q: %w", item, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("通配符 %q 没有匹配到文件", item)
+			}
+		}
+
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				paths = append(paths, m)
+				continue
+			}
+			err = filepath.WalkDir(m, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && ruleExts[strings.ToLower(filepath.Ext(p))] {
+					paths = append(paths, p)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ReadRules 读取单个指纹库文件并转换为规则，不做编译
+func ReadRules(path string) ([]FingerprintRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRuleData(path, data)
+}
+
+// EmbeddedRules 读取内置指纹库
+func EmbeddedRules() ([]FingerprintRule, error) {
+	return parseRuleData(EmbeddedSource, resource.Fingers)
+}
+
+func parseRuleData(path string, data []byte) ([]FingerprintRule, error) {
+	fps, issues, err := ParseRules(path, data, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		gologger.Info().Msgf("指纹转换跳过 %s", issue)
+	}
+	return fps, nil
+}
+
+// ReadRuleFiles 读取内置指纹库和 spec 指定的全部文件，内置指纹库排在第一个
+func ReadRuleFiles(spec string) ([]RuleFile, error) {
+	embedded, err := EmbeddedRules()
+	if err != nil {
+		return nil, err
+	}
+	files := []RuleFile{{Path: EmbeddedSource, Rules: embedded}}
+
+	paths, err := ExpandRulePaths(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		rules, err := ReadRules(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, RuleFile{Path: path, Rules: rules})
+	}
+	return files, nil
+}
+
+// MergeRules 合并多个文件的规则，用户文件中出现的 CMS 名会覆盖内置指纹库中的同名规则
+func MergeRules(files []RuleFile) []FingerprintRule {
+	overridden := make(map[string]bool)
+	for _, f := range files {
+		if f.Path == EmbeddedSource {
+			continue
+		}
+		for _, r := range f.Rules {
+			overridden[r.CMS] = true
+		}
+	}
+
+	var merged []FingerprintRule
+	for _, f := range files {
+		for _, r := range f.Rules {
+			if f.Path == EmbeddedSource && overridden[r.CMS] {
+				continue
+			}
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// LoadFingerprints 加载内置指纹库和 spec 指定的指纹文件（文件、目录或通配符）并编译，
+// 无效规则在加载时报错，错误信息中带有所在文件和规则下标
+func LoadFingerprints(spec string) (*RuleSet, error) {
+	files, err := ReadRuleFiles(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先逐个文件编译，保证错误信息指向具体文件
+	var errs []error
+	for _, f := range files {
+		if _, err := CompileRules(f.Rules, f.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return CompileRules(MergeRules(files), "merged")
+}
@@ -0,0 +1,239 @@
+package finger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeRuleFiles 在临时目录下创建文件，返回目录
+func writeRuleFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandRulePaths(t *testing.T) {
+	dir := writeRuleFiles(t, map[string]string{
+		"a.json":          "[]",
+		"b.json":          "[]",
+		"notes.txt":       "",
+		"sub/c.yaml":      "",
+		"sub/d.YML":       "",
+		"sub/deep/e.json": "[]",
+	})
+	p := func(names ...string) []string {
+		var paths []string
+		for _, n := range names {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(n)))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		want []string
+		err  string
+	}{
+		{name: "单个文件", spec: p("b.json")[0], want: p("b.json")},
+		{name: "不检查扩展名", spec: p("notes.txt")[0], want: p("notes.txt")},
+		{name: "目录递归且按扩展名过滤", spec: dir, want: p("a.json", "b.json", "sub/c.yaml", "sub/d.YML", "sub/deep/e.json")},
+		{name: "子目录", spec: p("sub")[0], want: p("sub/c.yaml", "sub/d.YML", "sub/deep/e.json")},
+		{name: "通配符", spec: filepath.Join(dir, "*.json"), want: p("a.json", "b.json")},
+		{name: "通配符匹配目录", spec: filepath.Join(dir, "s*"), want: p("sub/c.yaml", "sub/d.YML", "sub/deep/e.json")},
+		{name: "逗号分隔并排序", spec: p("sub/c.yaml")[0] + " , ," + p("a.json")[0], want: p("a.json", "sub/c.yaml")},
+		{name: "空参数", spec: " , ", want: nil},
+		{name: "路径不存在", spec: p("missing.json")[0], err: "missing.json"},
+		{name: "列表中有不存在的路径", spec: p("a.json")[0] + "," + p("missing")[0], err: "missing"},
+		{name: "通配符没有匹配", spec: filepath.Join(dir, "*.xml"), err: "没有匹配到文件"},
+		{name: "无效通配符", spec: filepath.Join(dir, "[a.json"), err: "无效的通配符"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandRulePaths(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("错误 %v，期望包含 %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("展开为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRules(t *testing.T) {
+	rule := func(cms, keyword string) FingerprintRule {
+		return FingerprintRule{CMS: cms, Level: 3, Logic: "and", Conditions: []Condition{
+			{Location: "body", Matcher: "match", Keywords: []string{keyword}},
+		}}
+	}
+	files := []RuleFile{
+		{Path: EmbeddedSource, Rules: []FingerprintRule{rule("A", "embedded-a"), rule("B", "embedded-b"), rule("C", "embedded-c")}},
+		{Path: "user1.json", Rules: []FingerprintRule{rule("B", "user1-b"), rule("D", "user1-d")}},
+		{Path: "user2.json", Rules: []FingerprintRule{rule("C", "user2-c"), rule("D", "user2-d")}},
+	}
+
+	var got []string
+	for _, r := range MergeRules(files) {
+		got = append(got, r.CMS+":"+r.Conditions[0].Keywords[0])
+	}
+	// 内置规则在前，被用户文件覆盖的同名规则去掉；用户文件之间的同名规则都保留
+	want := []string{"A:embedded-a", "B:user1-b", "D:user1-d", "C:user2-c", "D:user2-d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("合并结果 %v，期望 %v", got, want)
+	}
+}
+
+func TestReadRuleFilesOverridesEmbedded(t *testing.T) {
+	embedded, err := EmbeddedRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) == 0 {
+		t.Skip("内置指纹库为空")
+	}
+	name := embedded[0].CMS
+
+	dir := writeRuleFiles(t, map[string]string{
+		"user.json": `[{"cms":"` + name + `","level":5,"logic":"and","tags":[],"conditions":[{"location":"body","matcher":"match","keywords":["user-override"]}]}]`,
+	})
+	files, err := ReadRuleFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Path != EmbeddedSource || files[1].Path != filepath.Join(dir, "user.json") {
+		t.Fatalf("文件顺序错误: %v", files)
+	}
+
+	merged := MergeRules(files)
+	if len(merged) != len(embedded) {
+		t.Fatalf("合并后 %d 条规则，期望 %d 条", len(merged), len(embedded))
+	}
+	var found []FingerprintRule
+	for _, r := range merged {
+		if r.CMS == name {
+			found = append(found, r)
+		}
+	}
+	if len(found) != 1 || found[0].Level != 5 || found[0].Conditions[0].Keywords[0] != "user-override" {
+		t.Fatalf("同名规则未被用户文件覆盖: %+v", found)
+	}
+	if merged[len(merged)-1].CMS != name {
+		t.Fatalf("用户规则应排在内置规则之后")
+	}
+
+	if _, err := ReadRuleFiles(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("路径不存在时应返回错误")
+	}
+}
+
+func TestReadRulesYAML(t *testing.T) {
+	dir := writeRuleFiles(t, map[string]string{
+		"acme.yaml": `# dfinger 规则
+- cms: Acme
+  level: 4
+  logic: and
+  tags: [acme]
+  conditions:
+    - location: title
+      matcher: match
+      keywords: [Acme 管理平台]
+    - logic: or
+      not: true
+      conditions:
+        - location: status
+          matcher: match
+          keywords: [404]
+  extractors:
+    - location: body
+      regex: 'Acme v([\d.]+)'
+  probe:
+    path: /acme/
+  samples:
+    positive:
+      - body: "<title>Acme 管理平台</title>Acme v1.2"
+        favicon: "123"
+`,
+		"hub.yml": `id: acme-hub
+info:
+  name: Acme Hub
+http:
+  - path:
+      - "{{BaseURL}}/"
+    matchers:
+      - type: word
+        words:
+          - acme
+`,
+	})
+
+	yamlPath := filepath.Join(dir, "acme.yaml")
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectFormat(yamlPath, data); got != FormatDfinger {
+		t.Fatalf("dfinger YAML 识别为 %s", got)
+	}
+
+	rules, err := ReadRules(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FingerprintRule{{
+		CMS:   "Acme",
+		Level: 4,
+		Logic: "and",
+		Tags:  []string{"acme"},
+		Conditions: []Condition{
+			{Location: "title", Matcher: "match", Keywords: []string{"Acme 管理平台"}},
+			{Logic: "or", Not: true, Conditions: []Condition{
+				{Location: "status", Matcher: "match", Keywords: []string{"404"}},
+			}},
+		},
+		Extractors: []Extractor{{Location: "body", Regex: `Acme v([\d.]+)`}},
+		Probe:      &Probe{Path: "/acme/"},
+		Samples: &RuleSamples{Positive: []Sample{
+			{Body: "<title>Acme 管理平台</title>Acme v1.2", FaviconHash: "123"},
+		}},
+	}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Fatalf("解析结果 %+v，期望 %+v", rules, want)
+	}
+	if _, err := CompileRules(rules, yamlPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一目录中的 FingerprintHub 模板仍按 Hub 格式导入
+	files, err := ReadRuleFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files[1:] {
+		for _, r := range f.Rules {
+			names = append(names, filepath.Base(f.Path)+":"+r.CMS)
+		}
+	}
+	if want := []string{"acme.yaml:Acme", "hub.yml:Acme Hub"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("加载结果 %v，期望 %v", names, want)
+	}
+}
@@ -0,0 +1,310 @@
+package finger
+
+import (
+	"dfinger/core/network"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ResponseData 单次检测的输入数据
+type ResponseData struct {
+	Resp        *http.Response
+	Body        string // 按页面编码转换为 UTF-8 后的响应体
+	Raw         []byte // 原始响应体，用于 hash 计算
+	Charset     string // 识别出的页面编码
+	Title       string
+	Meta        PageMeta  // 页面元信息，非 HTML 响应为空
+	JS          string    // 页面引用的同源 JS/CSS 文件内容，开启 -js 时才下载
+	Cert        *CertInfo // HTTPS 证书，非 HTTPS 为 nil
+	JARM        string    // 目标端口的 JARM 指纹，开启 -jarm 时才计算
+	FaviconHash string
+	Path        string
+}
+
+// newResponseData 由响应构造检测输入，解析页面元信息并提取标题。
+// 编码沿用读取响应体时识别的结果，响应体未经 network.ResetBody 重置时才重新识别
+func newResponseData(resp *http.Response, body string, raw []byte, faviconHash string, path string) *ResponseData {
+	ctype := resp.Header.Get("Content-Type")
+	charset, ok := network.BodyCharset(resp)
+	if !ok {
+		charset = network.DetectCharset(raw, ctype)
+	}
+	in := &ResponseData{
+		Resp:        resp,
+		Body:        body,
+		Raw:         raw,
+		Charset:     charset,
+		FaviconHash: faviconHash,
+		Path:        path,
+		Cert:        ParseCert(resp),
+	}
+	if isHTML(ctype, body) {
+		in.Meta = ExtractMeta(body)
+	}
+	in.Title = pageTitle(strings.ReplaceAll(strconv.Itoa(resp.StatusCode), "206", "200"), ctype, body, resp.Header, in.Meta, 40)
+	return in
+}
+
+// 匹配位置类型
+const (
+	locHeader      = iota // 全部响应头
+	locBody               // 响应体
+	locTitle              // 标题
+	locFavicon            // favicon hash
+	locPath               // 请求路径
+	locStatus             // 状态码，match 时按数值比较
+	locCookie             // Set-Cookie 中的 name=value
+	locServer             // Server 响应头
+	locContentType        // Content-Type 响应头
+	locBodyHash           // 原始响应体的 mmh3 hash
+	locGenerator          // <meta name="generator">
+	locDescription        // <meta name="description">
+	locKeywords           // <meta name="keywords">
+	locOGTitle            // <meta property="og:title">
+	locH1                 // 第一个 <h1>
+	locCanonical          // <link rel="canonical">
+	locCharset            // 页面编码
+	locJS                 // 同源 JS/CSS 文件内容
+	locCert               // HTTPS 证书的使用者、颁发者、备用名称和序列号
+	locJARM               // JARM TLS 指纹
+	locNamedHeader        // header:<Name> 指定的单个响应头
+)
+
+var locationKinds = map[string]int{
+	"header":       locHeader,
+	"body":         locBody,
+	"title":        locTitle,
+	"favicon":      locFavicon,
+	"path":         locPath,
+	"status":       locStatus,
+	"cookie":       locCookie,
+	"server":       locServer,
+	"content_type": locContentType,
+	"body_hash":    locBodyHash,
+	"generator":    locGenerator,
+	"description":  locDescription,
+	"keywords":     locKeywords,
+	"og_title":     locOGTitle,
+	"h1":           locH1,
+	"canonical":    locCanonical,
+	"charset":      locCharset,
+	"js":           locJS,
+	"cert":         locCert,
+	"jarm":         locJARM,
+}
+
+// locationSlot 规则集中实际用到的一个匹配位置，header:<Name> 每个头名占一个槽位
+type locationSlot struct {
+	kind   int
+	header string
+}
+
+// parseLocation 解析位置字符串，返回规范化后的名称
+func parseLocation(name string) (locationSlot, string, error) {
+	if kind, ok := locationKinds[name]; ok {
+		return locationSlot{kind: kind}, name, nil
+	}
+	if h, ok := strings.CutPrefix(name, "header:"); ok && strings.TrimSpace(h) != "" {
+		h = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(h))
+		return locationSlot{kind: locNamedHeader, header: h}, "header:" + h, nil
+	}
+	return locationSlot{}, "", fmt.Errorf("未知的匹配位置: %q", name)
+}
+
+// location 返回位置对应的槽位下标，首次出现时分配
+func (rs *RuleSet) location(name string) (int, error) {
+	slot, key, err := parseLocation(name)
+	if err != nil {
+		return 0, err
+	}
+	if idx, ok := rs.locIndex[key]; ok {
+		return idx, nil
+	}
+	idx := len(rs.locations)
+	rs.locations = append(rs.locations, slot)
+	rs.matchers = append(rs.matchers, newACMatcher())
+	rs.locIndex[key] = idx
+	return idx, nil
+}
+
+// uses 规则集中是否有条件或版本提取用到该类位置
+func (rs *RuleSet) uses(kind int) bool {
+	for _, slot := range rs.locations {
+		if slot.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContext 单次响应的匹配数据，每个位置按需取值，且最多只用自动机扫描一次
+type matchContext struct {
+	rules   *RuleSet
+	input   *ResponseData
+	data    []string
+	loaded  []bool
+	hits    [][]uint64
+	scanned []bool
+}
+
+func newMatchContext(rules *RuleSet, input *ResponseData) *matchContext {
+	n := len(rules.locations)
+	return &matchContext{
+		rules:   rules,
+		input:   input,
+		data:    make([]string, n),
+		loaded:  make([]bool, n),
+		hits:    make([][]uint64, n),
+		scanned: make([]bool, n),
+	}
+}
+
+// dataAt 返回该位置的原始数据
+func (mc *matchContext) dataAt(loc int) string {
+	if !mc.loaded[loc] {
+		mc.data[loc] = mc.input.locationData(mc.rules.locations[loc])
+		mc.loaded[loc] = true
+	}
+	return mc.data[loc]
+}
+
+// hitsAt 返回该位置命中的关键字位图，首次访问时扫描
+func (mc *matchContext) hitsAt(loc int) []uint64 {
+	if !mc.scanned[loc] {
+		if m := mc.rules.matchers[loc]; m != nil {
+			mc.hits[loc] = m.scan(strings.ToLower(mc.dataAt(loc)))
+		}
+		mc.scanned[loc] = true
+	}
+	return mc.hits[loc]
+}
+
+func (in *ResponseData) locationData(slot locationSlot) string {
+	switch slot.kind {
+	case locBody:
+		return in.Body
+	case locTitle:
+		return in.Title
+	case locFavicon:
+		return in.FaviconHash
+	case locPath:
+		return in.Path
+	case locBodyHash:
+		if in.Raw == nil {
+			return ""
+		}
+		return Mmh3Hash32(in.Raw)
+	case locGenerator:
+		return in.Meta.Generator
+	case locDescription:
+		return in.Meta.Description
+	case locKeywords:
+		return in.Meta.Keywords
+	case locOGTitle:
+		return in.Meta.OGTitle
+	case locH1:
+		return in.Meta.H1
+	case locCanonical:
+		return in.Meta.Canonical
+	case locCharset:
+		return in.Charset
+	case locJS:
+		return in.JS
+	case locCert:
+		if in.Cert == nil {
+			return ""
+		}
+		return in.Cert.locationText()
+	case locJARM:
+		return in.JARM
+	}
+
+	resp := in.Resp
+	if resp == nil {
+		return ""
+	}
+	switch slot.kind {
+	case locHeader:
+		return joinHeaders(resp)
+	case locStatus:
+		return strconv.Itoa(resp.StatusCode)
+	case locCookie:
+		var cookies []string
+		for _, c := range resp.Cookies() {
+			cookies = append(cookies, c.Name+"="+c.Value)
+		}
+		return strings.Join(cookies, "\n")
+	case locServer:
+		return resp.Header.Get("Server")
+	case locContentType:
+		return resp.Header.Get("Content-Type")
+	case locNamedHeader:
+		return strings.Join(resp.Header.Values(slot.header), ",")
+	}
+	return ""
+}
+
+// joinHeaders 合并所有响应头字段内容
+func joinHeaders(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	var headers []string
+	for k := range resp.Header {
+		headers = append(headers, k+": "+strings.Join(resp.Header.Values(k), ","))
+	}
+	return strings.Join(headers, "\n")
+}
+
+// statusMatcher 状态码比较条件，支持 200、>=400、<300、!=404、200-299 等写法
+type statusMatcher struct {
+	op       string
+	min, max int
+}
+
+func parseStatusMatcher(expr string) (statusMatcher, error) {
+	expr = strings.TrimSpace(expr)
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if v, ok := strings.CutPrefix(expr, op); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return statusMatcher{}, fmt.Errorf("无效的状态码条件: %q", expr)
+			}
+			return statusMatcher{op: op, min: n}, nil
+		}
+	}
+	if lo, hi, ok := strings.Cut(expr, "-"); ok {
+		min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || min > max {
+			return statusMatcher{}, fmt.Errorf("无效的状态码范围: %q", expr)
+		}
+		return statusMatcher{op: "-", min: min, max: max}, nil
+	}
+	n, err := strconv.Atoi(expr)
+	if err != nil {
+		return statusMatcher{}, fmt.Errorf("无效的状态码条件: %q", expr)
+	}
+	return statusMatcher{op: "=", min: n}, nil
+}
+
+func (s statusMatcher) match(code int) bool {
+	switch s.op {
+	case ">=":
+		return code >= s.min
+	case "<=":
+		return code <= s.min
+	case "!=":
+		return code != s.min
+	case ">":
+		return code > s.min
+	case "<":
+		return code < s.min
+	case "-":
+		return code >= s.min && code <= s.max
+	}
+	return code == s.min
+}
@@ -0,0 +1,184 @@
+package finger
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/http"
+	"strings"
+)
+
+// LoginInfo 登录页面分析结果
+type LoginInfo struct {
+	Action   string   `json:"action,omitempty"`   // 登录表单提交地址
+	Method   string   `json:"method,omitempty"`   // 登录表单提交方式
+	Fields   []string `json:"fields,omitempty"`   // 登录表单字段名
+	Password bool     `json:"password,omitempty"` // 是否有密码输入框
+	Captcha  bool     `json:"captcha,omitempty"`  // 是否有验证码
+	SSO      string   `json:"sso,omitempty"`      // 跳转到的单点登录地址
+	Auth     string   `json:"auth,omitempty"`     // HTTP 认证方式，如 Basic、Digest、NTLM
+}
+
+// 内置登录页面指纹，命中时追加到识别结果中
+const (
+	LoginCMS   = "登录页面"
+	LoginTag   = "login"
+	LoginLevel = 3
+)
+
+// 验证码图片和输入框的常见命名
+var captchaKeywords = []string{"captcha", "kaptcha", "verifycode", "verify_code", "checkcode", "check_code", "validatecode", "validcode", "randcode", "yzm", "验证码"}
+
+// 单点登录地址的常见特征
+var ssoKeywords = []string{
+	"/cas/login", "/sso/", "/sso.", "/oauth2/authorize", "/oauth/authorize", "/adfs/ls",
+	"/saml", "/idp/", "/openid-connect/auth", "/auth/realms/", "login.microsoftonline.com",
+}
+
+// loginForm 页面中的一个表单，表单外的输入框归入 action 为空的隐式表单
+type loginForm struct {
+	action   string
+	method   string
+	fields   []string
+	password bool
+}
+
+// AnalyzeLogin 分析响应是否为登录页面：带密码框的表单、跳转到单点登录、HTTP 认证，
+// 都不满足时返回 nil
+func AnalyzeLogin(in *ResponseData) *LoginInfo {
+	info := &LoginInfo{}
+
+	var ctype string
+	if resp := in.Resp; resp != nil {
+		ctype = resp.Header.Get("Content-Type")
+		if resp.StatusCode == http.StatusUnauthorized {
+			if auth := resp.Header.Get("WWW-Authenticate"); auth != "" {
+				info.Auth, _, _ = strings.Cut(auth, " ")
+			}
+		}
+		info.SSO = ssoURL(resp)
+	}
+
+	if isHTML(ctype, in.Body) {
+		forms, captcha := parseForms(in.Body)
+		// 优先取真实表单，最后才是表单外的输入框
+		for _, f := range append(forms[1:], forms[0]) {
+			if f.password {
+				info.Action, info.Method, info.Fields, info.Password = f.action, f.method, f.fields, true
+				break
+			}
+		}
+		info.Captcha = captcha && (info.Password || info.SSO != "")
+		if info.SSO == "" && info.Password && isSSO(info.Action) {
+			info.SSO = info.Action
+		}
+	}
+
+	if !info.Password && info.SSO == "" && info.Auth == "" {
+		return nil
+	}
+	return info
+}
+
+// Evidence 命中依据，作为内置登录指纹的匹配关键字
+func (l *LoginInfo) Evidence() []string {
+	var evidence []string
+	if l.Password {
+		evidence = append(evidence, "password")
+	}
+	if l.Captcha {
+		evidence = append(evidence, "captcha")
+	}
+	if l.SSO != "" {
+		evidence = append(evidence, "sso:"+l.SSO)
+	}
+	if l.Auth != "" {
+		evidence = append(evidence, "auth:"+l.Auth)
+	}
+	return evidence
+}
+
+// ssoURL 返回重定向目标或跟随跳转后的最终地址中的单点登录地址
+func ssoURL(resp *http.Response) string {
+	if loc := resp.Header.Get("Location"); loc != "" && isSSO(loc) {
+		return loc
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		if u := resp.Request.URL.String(); isSSO(u) {
+			return u
+		}
+	}
+	return ""
+}
+
+func isSSO(u string) bool {
+	u = strings.ToLower(u)
+	for _, kw := range ssoKeywords {
+		if strings.Contains(u, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCaptcha(values ...string) bool {
+	for _, v := range values {
+		v = strings.ToLower(v)
+		for _, kw := range captchaKeywords {
+			if strings.Contains(v, kw) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseForms 解析页面中的表单和输入框，同时判断是否有验证码图片或输入框
+func parseForms(body string) ([]loginForm, bool) {
+	var (
+		forms   = []loginForm{{}} // 第一个为表单外的输入框
+		current = 0
+		captcha bool
+	)
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return forms, captcha
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Form {
+				current = 0
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			var attrs map[string]string
+			if hasAttr {
+				attrs = tagAttrs(z)
+			}
+			switch atom.Lookup(name) {
+			case atom.Form:
+				forms = append(forms, loginForm{action: strings.TrimSpace(attrs["action"]), method: strings.ToUpper(attrs["method"])})
+				current = len(forms) - 1
+			case atom.Input, atom.Select, atom.Textarea:
+				f := &forms[current]
+				field := attrs["name"]
+				if field == "" {
+					field = attrs["id"]
+				}
+				if field != "" {
+					f.fields = append(f.fields, field)
+				}
+				if strings.EqualFold(attrs["type"], "password") {
+					f.password = true
+				}
+				if isCaptcha(attrs["name"], attrs["id"], attrs["placeholder"]) {
+					captcha = true
+				}
+			case atom.Img:
+				if isCaptcha(attrs["src"], attrs["id"], attrs["class"], attrs["alt"], attrs["title"]) {
+					captcha = true
+				}
+			}
+		}
+	}
+}
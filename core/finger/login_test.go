@@ -0,0 +1,140 @@
+package finger
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeLogin(t *testing.T) {
+	html := http.Header{"Content-Type": {"text/html; charset=utf-8"}}
+	tests := []struct {
+		name     string
+		status   int
+		header   http.Header
+		url      string
+		body     string
+		want     *LoginInfo
+		evidence []string
+	}{
+		{
+			name:   "带密码框的表单",
+			header: html,
+			body: `<form action="/login.do" method="post">
+				<input type="text" name="username">
+				<input type="PASSWORD" id="pwd">
+				<select name="lang"></select>
+				<input type="submit">
+			</form>`,
+			want:     &LoginInfo{Action: "/login.do", Method: "POST", Fields: []string{"username", "pwd", "lang"}, Password: true},
+			evidence: []string{"password"},
+		},
+		{
+			name:   "多个表单时取带密码框的那个",
+			header: html,
+			body: `<form action="/search" method="get"><input name="q"></form>
+				<form action=" /auth " method="Post"><input name="user"><input type="password" name="pass"><textarea name="memo"></textarea></form>`,
+			want:     &LoginInfo{Action: "/auth", Method: "POST", Fields: []string{"user", "pass", "memo"}, Password: true},
+			evidence: []string{"password"},
+		},
+		{
+			name:   "表单外的密码框",
+			header: html,
+			body: `<div id="app"><input name="account"><input type="password" name="password">
+				<button onclick="login()">登录</button></div>`,
+			want:     &LoginInfo{Fields: []string{"account", "password"}, Password: true},
+			evidence: []string{"password"},
+		},
+		{
+			name:   "验证码图片",
+			header: html,
+			body: `<form action="/login" method="post"><input name="u"><input type="password" name="p">
+				<img src="/servlet/kaptcha.jpg"></form>`,
+			want:     &LoginInfo{Action: "/login", Method: "POST", Fields: []string{"u", "p"}, Password: true, Captcha: true},
+			evidence: []string{"password", "captcha"},
+		},
+		{
+			name:   "验证码输入框",
+			header: html,
+			body: `<form><input name="u"><input type="password" name="p">
+				<input name="code" placeholder="请输入验证码"></form>`,
+			want:     &LoginInfo{Fields: []string{"u", "p", "code"}, Password: true, Captcha: true},
+			evidence: []string{"password", "captcha"},
+		},
+		{
+			name:   "没有登录框时验证码不算",
+			header: html,
+			body:   `<form action="/comment"><input name="text"><input name="verifycode"></form>`,
+			want:   nil,
+		},
+		{
+			name:   "没有表单",
+			header: html,
+			body:   `<html><head><title>首页</title></head><body><a href="/login">登录</a></body></html>`,
+			want:   nil,
+		},
+		{
+			name:   "非 HTML 响应不解析表单",
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   `{"html":"<input type=\"password\">"}`,
+			want:   nil,
+		},
+		{
+			name:     "跳转到单点登录",
+			status:   302,
+			header:   http.Header{"Location": {"https://sso.example.com/cas/login?service=x"}},
+			want:     &LoginInfo{SSO: "https://sso.example.com/cas/login?service=x"},
+			evidence: []string{"sso:https://sso.example.com/cas/login?service=x"},
+		},
+		{
+			name:   "跟随跳转后的单点登录页面",
+			header: html,
+			url:    "https://idp.example.com/auth/realms/main/protocol/openid-connect/auth?client_id=a",
+			body:   `<form action="/auth/realms/main/login-actions/authenticate"><input name="username"><input type="password" name="password"><img src="/captcha.png"></form>`,
+			want: &LoginInfo{Action: "/auth/realms/main/login-actions/authenticate", Fields: []string{"username", "password"}, Password: true, Captcha: true,
+				SSO: "https://idp.example.com/auth/realms/main/protocol/openid-connect/auth?client_id=a"},
+			evidence: []string{"password", "captcha", "sso:https://idp.example.com/auth/realms/main/protocol/openid-connect/auth?client_id=a"},
+		},
+		{
+			name:     "表单提交到单点登录",
+			header:   html,
+			body:     `<form action="https://login.example.com/cas/login" method="post"><input type="password" name="p"></form>`,
+			want:     &LoginInfo{Action: "https://login.example.com/cas/login", Method: "POST", Fields: []string{"p"}, Password: true, SSO: "https://login.example.com/cas/login"},
+			evidence: []string{"password", "sso:https://login.example.com/cas/login"},
+		},
+		{
+			name:     "HTTP 认证",
+			status:   401,
+			header:   http.Header{"Www-Authenticate": {`Basic realm="router"`}},
+			want:     &LoginInfo{Auth: "Basic"},
+			evidence: []string{"auth:Basic"},
+		},
+		{
+			name:   "非 401 的认证头不算",
+			status: 200,
+			header: http.Header{"Www-Authenticate": {`Basic realm="x"`}, "Content-Type": {"text/plain"}},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == 0 {
+				status = 200
+			}
+			resp := &http.Response{StatusCode: status, Header: tt.header}
+			if tt.url != "" {
+				u, _ := url.Parse(tt.url)
+				resp.Request = &http.Request{URL: u}
+			}
+			got := AnalyzeLogin(&ResponseData{Resp: resp, Body: tt.body})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("AnalyzeLogin = %+v\n期望 %+v", got, tt.want)
+			}
+			if got != nil && !reflect.DeepEqual(got.Evidence(), tt.evidence) {
+				t.Fatalf("Evidence = %q，期望 %q", got.Evidence(), tt.evidence)
+			}
+		})
+	}
+}
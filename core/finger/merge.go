@@ -0,0 +1,164 @@
+package finger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMergeKey -merge on 时的资产合并依据：主机、标题、响应体 hash、favicon 都相同视为同一资产
+const DefaultMergeKey = "host,title,body_hash,favicon"
+
+// mergeFields 可用于合并的字段
+var mergeFields = map[string]func(r *ScanResult) string{
+	"host":      func(r *ScanResult) string { return r.Host },
+	"ip":        func(r *ScanResult) string { return r.IP },
+	"port":      func(r *ScanResult) string { return strconv.Itoa(r.Port) },
+	"scheme":    func(r *ScanResult) string { return r.Scheme },
+	"status":    func(r *ScanResult) string { return strconv.Itoa(r.StatusCode) },
+	"title":     func(r *ScanResult) string { return r.Title },
+	"body_hash": func(r *ScanResult) string { return r.BodyHash },
+	"favicon":   func(r *ScanResult) string { return r.IconHash },
+}
+
+// Endpoint 资产的一个访问入口
+type Endpoint struct {
+	URL        string `json:"url"`
+	IP         string `json:"ip"`
+	Port       int    `json:"port"`
+	Scheme     string `json:"scheme"`
+	StatusCode int    `json:"status_code"`
+}
+
+// ParseMergeKey 解析逗号分隔的合并字段，为空或 off 表示不合并，返回 nil；on 使用 DefaultMergeKey
+func ParseMergeKey(spec string) ([]string, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch spec {
+	case "", "off", "none", "false", "0":
+		return nil, nil
+	case "on", "true", "1":
+		spec = DefaultMergeKey
+	}
+	var fields []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := mergeFields[name]; !ok {
+			return nil, fmt.Errorf("未知的合并字段: %q", name)
+		}
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("合并字段为空")
+	}
+	return fields, nil
+}
+
+// MergeSink 按合并字段把结果归并为资产，扫描结束时每个资产输出一条记录到下游输出，
+// 记录中的 Endpoints 列出提供相同内容的所有地址
+type MergeSink struct {
+	fields []string
+	groups map[string]*ScanResult
+	order  []string
+	next   []Sink
+}
+
+func NewMergeSink(fields []string, next []Sink) *MergeSink {
+	return &MergeSink{fields: fields, groups: make(map[string]*ScanResult), next: next}
+}
+
+func (m *MergeSink) key(r *ScanResult) string {
+	values := make([]string, len(m.fields))
+	for i, name := range m.fields {
+		values[i] = mergeFields[name](r)
+	}
+	return strings.Join(values, "\x00")
+}
+
+func (m *MergeSink) Write(r *ScanResult) error {
+	key := m.key(r)
+	asset, ok := m.groups[key]
+	if !ok {
+		// 第一个结果作为资产记录，复制一份以免修改其他输出持有的结果
+		copied := *r
+		copied.Fingers = append([]DetectionResult{}, r.Fingers...)
+		copied.Endpoints = []Endpoint{endpointOf(r)}
+		m.groups[key] = &copied
+		m.order = append(m.order, key)
+		return nil
+	}
+	asset.merge(r)
+	return nil
+}
+
+// Flush 资产在 Close 时才输出
+func (m *MergeSink) Flush() error {
+	return nil
+}
+
+func (m *MergeSink) Close() error {
+	var firstErr error
+	for _, key := range m.order {
+		asset := m.groups[key]
+		sort.Slice(asset.Endpoints, func(i, j int) bool {
+			a, b := asset.Endpoints[i], asset.Endpoints[j]
+			if a.URL != b.URL {
+				return a.URL < b.URL
+			}
+			return a.IP < b.IP
+		})
+		for _, s := range m.next {
+			if err := s.Write(asset); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for _, s := range m.next {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func endpointOf(r *ScanResult) Endpoint {
+	return Endpoint{URL: r.URL, IP: r.IP, Port: r.Port, Scheme: r.Scheme, StatusCode: r.StatusCode}
+}
+
+// merge 把同一资产的另一个结果并入：记录入口，合并指纹和匹配关键字，补全缺失的信息
+func (r *ScanResult) merge(other *ScanResult) {
+	r.Endpoints = append(r.Endpoints, endpointOf(other))
+
+	for _, f := range other.Fingers {
+		found := false
+		for i := range r.Fingers {
+			if r.Fingers[i].CMS == f.CMS && r.Fingers[i].Version == f.Version {
+				r.Fingers[i].Matched = uniqueStrings(append(append([]string{}, r.Fingers[i].Matched...), f.Matched...))
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Fingers = append(r.Fingers, f)
+		}
+	}
+
+	if r.Cert == nil {
+		r.Cert = other.Cert
+	}
+	if r.Login == nil {
+		r.Login = other.Login
+	}
+	if r.JARM == "" {
+		r.JARM = other.JARM
+	}
+	if r.Icon == nil {
+		r.Icon = other.Icon
+	}
+	r.CDN.IsCDN = r.CDN.IsCDN || other.CDN.IsCDN
+	r.CDN.CDNIPs = uniqueStrings(append(append([]string{}, r.CDN.CDNIPs...), other.CDN.CDNIPs...))
+	r.CDN.RealIPs = uniqueStrings(append(append([]string{}, r.CDN.RealIPs...), other.CDN.RealIPs...))
+}
@@ -0,0 +1,179 @@
+package finger
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMergeKey(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{spec: "off", want: nil},
+		{spec: " OFF ", want: nil},
+		{spec: "on", want: []string{"host", "title", "body_hash", "favicon"}},
+		{spec: "Host, title,,", want: []string{"host", "title"}},
+		{spec: "host,unknown", wantErr: true},
+		{spec: ",", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMergeKey(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMergeKey(%q) 错误 %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMergeKey(%q) = %q，期望 %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+// memSink 记录写入的结果
+type memSink struct {
+	results []ScanResult
+	closed  bool
+}
+
+func (s *memSink) Write(r *ScanResult) error {
+	s.results = append(s.results, *r)
+	return nil
+}
+
+func (s *memSink) Flush() error { return nil }
+
+func (s *memSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestMergeSink(t *testing.T) {
+	next := &memSink{}
+	m := NewMergeSink([]string{"host", "title", "body_hash", "favicon"}, []Sink{next})
+
+	first := &ScanResult{
+		URL: "https://www.example.com:8443/", Host: "www.example.com", IP: "10.0.0.2", Port: 8443, Scheme: "https", StatusCode: 200,
+		Title: "首页", BodyHash: "1", IconHash: "2",
+		Fingers: []DetectionResult{{CMS: "nginx", Matched: []string{"server"}}},
+		CDN:     CDNResult{CDNIPs: []string{}, RealIPs: []string{"10.0.0.2"}},
+	}
+	second := &ScanResult{
+		URL: "http://www.example.com/", Host: "www.example.com", IP: "10.0.0.1", Port: 80, Scheme: "http", StatusCode: 200,
+		Title: "首页", BodyHash: "1", IconHash: "2", JARM: "jarm",
+		Fingers: []DetectionResult{{CMS: "nginx", Matched: []string{"server", "body"}}, {CMS: "tomcat", Version: "9"}},
+		Cert:    &CertInfo{Subject: "CN=www.example.com"},
+		Login:   &LoginInfo{Password: true},
+		CDN:     CDNResult{IsCDN: true, CDNIPs: []string{"1.1.1.1"}, RealIPs: []string{"10.0.0.2", "10.0.0.1"}},
+	}
+	other := &ScanResult{URL: "http://www.example.com:8080/", Host: "www.example.com", Port: 8080, Title: "管理后台", BodyHash: "3"}
+	// 同一地址重复扫描时也只记录为一个资产
+	again := *first
+
+	for _, r := range []*ScanResult{first, other, second, &again} {
+		if err := m.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(next.results) != 0 {
+		t.Fatalf("合并时 Close 前不应输出结果: %d 条", len(next.results))
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !next.closed {
+		t.Fatal("下游输出没有关闭")
+	}
+	if len(next.results) != 2 {
+		t.Fatalf("输出 %d 个资产，期望 2", len(next.results))
+	}
+
+	asset := next.results[0]
+	wantEnds := []Endpoint{
+		{URL: "http://www.example.com/", IP: "10.0.0.1", Port: 80, Scheme: "http", StatusCode: 200},
+		{URL: "https://www.example.com:8443/", IP: "10.0.0.2", Port: 8443, Scheme: "https", StatusCode: 200},
+		{URL: "https://www.example.com:8443/", IP: "10.0.0.2", Port: 8443, Scheme: "https", StatusCode: 200},
+	}
+	if !reflect.DeepEqual(asset.Endpoints, wantEnds) {
+		t.Errorf("资产入口 %+v\n期望 %+v", asset.Endpoints, wantEnds)
+	}
+	wantFingers := []DetectionResult{{CMS: "nginx", Matched: []string{"server", "body"}}, {CMS: "tomcat", Version: "9"}}
+	if !reflect.DeepEqual(asset.Fingers, wantFingers) {
+		t.Errorf("合并指纹 %+v\n期望 %+v", asset.Fingers, wantFingers)
+	}
+	// 资产记录以第一个结果为准，缺失的信息从其他结果补全
+	if asset.URL != first.URL || asset.Cert != second.Cert || asset.Login != second.Login || asset.JARM != "jarm" {
+		t.Errorf("资产信息错误: %+v", asset)
+	}
+	if !asset.CDN.IsCDN || !reflect.DeepEqual(asset.CDN.CDNIPs, []string{"1.1.1.1"}) || !reflect.DeepEqual(asset.CDN.RealIPs, []string{"10.0.0.2", "10.0.0.1"}) {
+		t.Errorf("CDN 信息错误: %+v", asset.CDN)
+	}
+	if next.results[1].URL != other.URL || len(next.results[1].Endpoints) != 1 {
+		t.Errorf("第二个资产错误: %+v", next.results[1])
+	}
+
+	// 合并不能修改其他输出持有的原始结果
+	if len(first.Fingers) != 1 || !reflect.DeepEqual(first.Fingers[0].Matched, []string{"server"}) || first.Endpoints != nil || first.Cert != nil {
+		t.Errorf("原始结果被修改: %+v", first)
+	}
+}
+
+func TestSinkPipelineMergeOff(t *testing.T) {
+	// 默认不合并，结果逐条写入并定时刷新，扫描过程中就能看到
+	files := openTestSinks(t, "")
+	emit(sinkResult(1))
+	emit(sinkResult(1))
+	deadline := time.Now().Add(5 * flushInterval)
+	for {
+		data, _ := os.ReadFile(files.text)
+		if len(data) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("不合并时结果没有及时写入")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	CloseSinks()
+	if n := checkSinkFiles(t, files); n != 2 {
+		t.Fatalf("不合并时 %d 条结果，期望 2", n)
+	}
+	for _, r := range readJSONLines(t, files.json) {
+		if r.Endpoints != nil {
+			t.Errorf("不合并时不应输出 endpoints: %+v", r.Endpoints)
+		}
+	}
+}
+
+func TestSinkPipelineMergeOn(t *testing.T) {
+	files := openTestSinks(t, "on")
+	// 两个资产各通过两个端口访问
+	for i := 0; i < 4; i++ {
+		r := sinkResult(i % 2)
+		r.Host = "www.example.com"
+		r.Port = 80 + i/2
+		r.URL = fmt.Sprintf("http://www.example.com:%d/", r.Port)
+		emit(r)
+	}
+	emit(sinkResult(9))
+	time.Sleep(2 * flushInterval)
+	if data, _ := os.ReadFile(files.text); len(data) > 0 {
+		t.Fatalf("合并时结果应在结束时输出: %s", data)
+	}
+	CloseSinks()
+
+	if n := checkSinkFiles(t, files); n != 3 {
+		t.Fatalf("合并后 %d 条结果，期望 3", n)
+	}
+	list := readJSONLines(t, files.json)
+	if len(list[0].Endpoints) != 2 || len(list[1].Endpoints) != 2 || len(list[2].Endpoints) != 1 {
+		t.Fatalf("资产入口错误: %+v", list)
+	}
+}
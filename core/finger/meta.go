@@ -0,0 +1,176 @@
+package finger
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// PageMeta 页面元信息
+type PageMeta struct {
+	Title       string `json:"title,omitempty"`       // <title>
+	Generator   string `json:"generator,omitempty"`   // <meta name="generator">
+	Description string `json:"description,omitempty"` // <meta name="description">
+	Keywords    string `json:"keywords,omitempty"`    // <meta name="keywords">
+	OGTitle     string `json:"og_title,omitempty"`    // <meta property="og:title">
+	H1          string `json:"h1,omitempty"`          // 第一个 <h1>
+	Canonical   string `json:"canonical,omitempty"`   // <link rel="canonical">
+	Charset     string `json:"charset,omitempty"`     // <meta charset> 或 http-equiv 中声明的编码
+}
+
+// ExtractMeta 用 HTML 词法分析提取页面元信息，标签名和属性名不区分大小写，
+// 每个字段取第一次出现的值。<head> 结束且拿到第一个 <h1> 后，或全部字段都已取到时停止解析
+func ExtractMeta(body string) PageMeta {
+	var meta PageMeta
+	z := html.NewTokenizer(strings.NewReader(body))
+	var text *string // 正在收集文本的字段
+	var buf strings.Builder
+	headDone := false // <head> 中的 meta、link 已经读完
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if text != nil && *text == "" {
+				*text = normalizeSpace(buf.String())
+			}
+			return meta
+		case html.TextToken:
+			if text != nil {
+				buf.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if text != nil && (a == atom.Title && text == &meta.Title || a == atom.H1 && text == &meta.H1) {
+				*text = normalizeSpace(buf.String())
+				text = nil
+			}
+			if a == atom.Head {
+				headDone = true
+			}
+			if meta.H1 != "" && (headDone || meta.complete()) {
+				return meta
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Body:
+				headDone = true
+			case atom.Title:
+				// 只取第一个 <title>
+				if meta.Title == "" && text == nil {
+					text = &meta.Title
+					buf.Reset()
+				}
+			case atom.H1:
+				if meta.H1 == "" && text == nil {
+					text = &meta.H1
+					buf.Reset()
+				}
+			case atom.Meta:
+				if hasAttr {
+					meta.readMeta(tagAttrs(z))
+				}
+			case atom.Link:
+				if hasAttr {
+					attrs := tagAttrs(z)
+					if meta.Canonical == "" && hasToken(attrs["rel"], "canonical") {
+						meta.Canonical = strings.TrimSpace(attrs["href"])
+					}
+				}
+			}
+		}
+	}
+}
+
+// complete 判断全部字段是否都已取到
+func (m *PageMeta) complete() bool {
+	return m.Title != "" && m.Generator != "" && m.Description != "" && m.Keywords != "" &&
+		m.OGTitle != "" && m.H1 != "" && m.Canonical != "" && m.Charset != ""
+}
+
+// readMeta 处理一个 <meta> 标签
+func (m *PageMeta) readMeta(attrs map[string]string) {
+	content := normalizeSpace(attrs["content"])
+	if cs := attrs["charset"]; cs != "" && m.Charset == "" {
+		m.Charset = strings.ToLower(strings.TrimSpace(cs))
+	}
+	if strings.EqualFold(attrs["http-equiv"], "content-type") && m.Charset == "" {
+		if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+			m.Charset = strings.ToLower(params["charset"])
+		}
+	}
+
+	name := strings.ToLower(attrs["name"])
+	if name == "" {
+		name = strings.ToLower(attrs["property"])
+	}
+	var field *string
+	switch name {
+	case "generator":
+		field = &m.Generator
+	case "description":
+		field = &m.Description
+	case "keywords":
+		field = &m.Keywords
+	case "og:title":
+		field = &m.OGTitle
+	}
+	if field != nil && *field == "" {
+		*field = content
+	}
+}
+
+// tagAttrs 读取当前标签的属性，属性名转为小写，重复属性取第一个
+func tagAttrs(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, val, more := z.TagAttr()
+		k := strings.ToLower(string(key))
+		if _, ok := attrs[k]; !ok {
+			attrs[k] = string(val)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// hasToken 判断空格分隔的属性值中是否包含某个值，如 rel="shortcut icon"
+func hasToken(value string, token string) bool {
+	for _, f := range strings.Fields(value) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSpace 合并连续空白，标题中常见的换行和缩进会影响展示
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateRunes 按字符截断，超出时加上 "..."，避免截断多字节字符
+func truncateRunes(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n]) + "..."
+}
+
+// isHTML 判断响应是否为 HTML，未声明 Content-Type 时按内容推测
+func isHTML(ctype string, body string) bool {
+	if ctype == "" {
+		if len(body) > 512 {
+			body = body[:512]
+		}
+		return strings.Contains(http.DetectContentType([]byte(body)), "html")
+	}
+	return strings.Contains(strings.ToLower(ctype), "html")
+}
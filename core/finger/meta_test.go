@@ -0,0 +1,126 @@
+package finger
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExtractMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want PageMeta
+	}{
+		{
+			name: "大写标签名",
+			body: `<HTML><HEAD><TITLE>Admin  Console</TITLE><META NAME="Generator" CONTENT="Acme 2.1"></HEAD></HTML>`,
+			want: PageMeta{Title: "Admin Console", Generator: "Acme 2.1"},
+		},
+		{
+			name: "title 带属性",
+			body: `<head><title lang="zh-CN" data-x=1>
+				统一身份认证
+			</title></head>`,
+			want: PageMeta{Title: "统一身份认证"},
+		},
+		{
+			name: "只取第一个 title",
+			body: `<head><title>first</title></head><body><svg><title>icon</title></svg></body>`,
+			want: PageMeta{Title: "first"},
+		},
+		{
+			name: "head 中的 meta 和 body 中的 h1",
+			body: `<html><head>
+				<meta charset="GBK">
+				<meta name="description" content="  登录  页面 ">
+				<meta name="keywords" content="a,b">
+				<meta property="og:title" content="OG">
+				<link rel="shortcut canonical" href=" https://example.com/ ">
+				</head><body><h1>Welcome <b>home</b></h1><h1>second</h1></body></html>`,
+			want: PageMeta{Description: "登录 页面", Keywords: "a,b", OGTitle: "OG", H1: "Welcome home", Canonical: "https://example.com/", Charset: "gbk"},
+		},
+		{
+			name: "h1 之后的 meta 和 link",
+			body: `<html><h1>Welcome</h1>
+				<meta name="generator" content="WordPress 6.4">
+				<meta name="description" content="desc">
+				<link rel="canonical" href="/home">`,
+			want: PageMeta{H1: "Welcome", Generator: "WordPress 6.4", Description: "desc", Canonical: "/home"},
+		},
+		{
+			name: "head 中出现 h1 时继续读完 head",
+			body: `<head><h1>Early</h1><meta name="generator" content="Late"></head><body><meta name="description" content="in body"></body>`,
+			want: PageMeta{H1: "Early", Generator: "Late"},
+		},
+		{
+			name: "http-equiv 声明编码",
+			body: `<meta http-equiv="content-type" content="text/html; charset=Big5">`,
+			want: PageMeta{Charset: "big5"},
+		},
+		{
+			name: "未闭合的 title",
+			body: `<title>broken page`,
+			want: PageMeta{Title: "broken page"},
+		},
+		{
+			name: "非 HTML",
+			body: `{"title":"json"}`,
+			want: PageMeta{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractMeta(tt.body); got != tt.want {
+				t.Fatalf("ExtractMeta = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageTitle(t *testing.T) {
+	html := http.Header{}
+	tests := []struct {
+		name  string
+		code  string
+		ctype string
+		body  string
+		want  string
+	}{
+		{"title", "200", "text/html", `<title>Login</title><h1>Header</h1>`, "Login"},
+		{"og:title 回退", "200", "text/html", `<meta property="og:title" content="OG"><h1>Header</h1>`, "OG"},
+		{"h1 回退", "200", "text/html", `<body><h1> Header
+			Text </h1></body>`, "Header Text"},
+		{"没有标题", "200", "text/html", `<body><p>x</p></body>`, "Unknown Title"},
+		{"按字符截断", "200", "text/html", `<title>统一身份认证平台统一身份认证平台</title>`, "统一身份认证平台统一身份..."},
+		{"纯文本按字符截断", "200", "text/plain", "中文中文中文中文中文中文中文", "中文中文中文中文中文中文..."},
+		{"重定向", "302", "text/html", `<title>x</title>`, "302-> "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := ExtractMeta(tt.body)
+			if got := pageTitle(tt.code, tt.ctype, tt.body, html, meta, 12); got != tt.want {
+				t.Fatalf("pageTitle = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abc", 3, "abc"},
+		{"abcd", 3, "abc..."},
+		{"中文标题", 4, "中文标题"},
+		{"中文标题很长", 3, "中文标..."},
+		{"a😀b", 2, "a😀..."},
+		{"anything", 0, "anything"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q，期望 %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
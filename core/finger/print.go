@@ -1,28 +1,24 @@
 package finger
 
 import (
-	"dfinger/common"
 	"fmt"
 	"github.com/logrusorgru/aurora"
-	"github.com/projectdiscovery/gologger"
-	"os"
 	"strings"
 )
 
-func PrintResult(
-	host string,
-	statusCode int,
-	title string,
-	contentLength int,
-	iconHash string,
-	fingers []DetectionResult,
-) {
+// PrintResult 输出单个目标的识别结果，交给结果管道写入控制台和各结果文件
+func PrintResult(r *ScanResult) {
+	emit(r)
+}
+
+// consoleLine 控制台展示格式，按状态码和指纹等级着色
+func consoleLine(r *ScanResult) string {
 	// Host 蓝色
-	hostColored := aurora.BrightBlue(host).String()
+	hostColored := aurora.BrightBlue(r.URL).String()
 
 	// 状态码颜色
 	var statusColored string
-	switch {
+	switch statusCode := r.StatusCode; {
 	case statusCode == 200:
 		statusColored = aurora.Green(fmt.Sprintf("%d", statusCode)).String()
 	case statusCode >= 300 && statusCode < 400:
@@ -34,56 +30,126 @@ func PrintResult(
 	}
 
 	// Title 青色
-	titleColored := aurora.Cyan(title).String()
+	titleColored := aurora.Cyan(r.Title).String()
 
 	// contentLength 紫色
-	lengthColored := aurora.Magenta(fmt.Sprintf("%d", contentLength)).String()
+	lengthColored := aurora.Magenta(fmt.Sprintf("%d", r.ContentLength)).String()
 
 	// iconHash 灰色
-	iconHashColored := aurora.Gray(12, iconHash).String()
+	iconHashColored := aurora.Gray(12, r.IconHash).String()
 
 	// fingers 按Level分颜色
 	var fingerStrs []string
-	for _, f := range fingers {
+	for _, f := range r.Fingers {
 		var fingerColored string
 		switch f.Level {
 		case 3:
-			fingerColored = aurora.Red(fmt.Sprintf("%s(L%d)", f.CMS, f.Level)).String()
+			fingerColored = aurora.Red(fingerLabel(f)).String()
 		case 2:
-			fingerColored = aurora.Yellow(fmt.Sprintf("%s(L%d)", f.CMS, f.Level)).String()
+			fingerColored = aurora.Yellow(fingerLabel(f)).String()
 		default:
-			fingerColored = aurora.Green(fmt.Sprintf("%s(L%d)", f.CMS, f.Level)).String()
+			fingerColored = aurora.Green(fingerLabel(f)).String()
 		}
 		fingerStrs = append(fingerStrs, fingerColored)
 	}
 
-	gologger.Info().Msgf(
-		"%s | %s | %s | [len:%s] | iconHash: %s | Finger: %s",
+	// 页面声明的 generator 通常直接给出程序和版本
+	var generator string
+	if r.Meta.Generator != "" {
+		generator = " | Generator: " + aurora.Cyan(r.Meta.Generator).String()
+	}
+
+	// 证书 CN 及自签名、过期、域名不匹配标记
+	var cert string
+	if r.Cert != nil {
+		cert = " | Cert: " + aurora.Gray(12, r.Cert.Name()).String()
+		if flags := r.Cert.Flags(); len(flags) > 0 {
+			cert += " " + aurora.Yellow("["+strings.Join(flags, ",")+"]").String()
+		}
+	}
+
+	var jarm string
+	if r.JARM != "" {
+		jarm = " | JARM: " + aurora.Gray(12, r.JARM).String()
+	}
+
+	cdn := cdnText(r)
+	if r.CDN.IsCDN {
+		cdn = aurora.Red(cdn).String()
+	}
+
+	return fmt.Sprintf(
+		"%s | %s | %s | [len:%s] | iconHash: %s | Finger: %s%s%s%s%s",
 		hostColored,
 		statusColored,
 		titleColored,
 		lengthColored,
 		iconHashColored,
 		strings.Join(fingerStrs, ", "),
+		generator,
+		cert,
+		jarm,
+		cdn,
 	)
+}
 
-	// 保存纯文本结果
-	plainFingerStrs := make([]string, len(fingers))
-	for i, f := range fingers {
-		plainFingerStrs[i] = fmt.Sprintf("%s(L%d)", f.CMS, f.Level)
+// textLine 结果文件中的纯文本格式
+func textLine(r *ScanResult) string {
+	plainFingerStrs := make([]string, len(r.Fingers))
+	for i, f := range r.Fingers {
+		plainFingerStrs[i] = fingerLabel(f)
 	}
 
-	plainOutput := fmt.Sprintf("[+] %s | %d | %s | [len:%d] | iconHash: %s | Finger: %s\n",
-		host, statusCode, title, contentLength, iconHash,
-		strings.Join(plainFingerStrs, ", "))
+	var generator, cert, jarm, endpoints string
+	if r.Meta.Generator != "" {
+		generator = " | Generator: " + r.Meta.Generator
+	}
+	if r.Cert != nil {
+		cert = " | Cert: " + r.Cert.Name()
+		if flags := r.Cert.Flags(); len(flags) > 0 {
+			cert += " [" + strings.Join(flags, ",") + "]"
+		}
+	}
+	if r.JARM != "" {
+		jarm = " | JARM: " + r.JARM
+	}
+	// 合并后的资产列出其他提供相同内容的地址
+	if len(r.Endpoints) > 1 {
+		urls := make([]string, len(r.Endpoints))
+		for i, e := range r.Endpoints {
+			urls[i] = e.URL
+			if e.IP != "" && e.IP != r.Host {
+				urls[i] += "(" + e.IP + ")"
+			}
+		}
+		endpoints = fmt.Sprintf(" | Endpoints(%d): %s", len(r.Endpoints), strings.Join(urls, ", "))
+	}
 
-	if common.Infos.OutputFile != "" {
-		f, err := os.OpenFile(common.Infos.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			gologger.Error().Msgf("无法写入输出文件: %s", err)
-			return
+	return fmt.Sprintf("[+] %s | %d | %s | [len:%d] | iconHash: %s | Finger: %s%s%s%s%s%s",
+		r.URL, r.StatusCode, r.Title, r.ContentLength, r.IconHash,
+		strings.Join(plainFingerStrs, ", "), generator, cert, jarm, cdnText(r), endpoints)
+}
+
+// cdnText CDN 判断结果：CDN 节点列出厂商和全部 CDN IP，域名目标列出解析出的真实 IP
+func cdnText(r *ScanResult) string {
+	var s string
+	if r.CDN.IsCDN {
+		s += " | CDN"
+		if r.CDN.Provider != "" {
+			s += "(" + r.CDN.Provider + ")"
 		}
-		defer f.Close()
-		_, _ = f.WriteString(plainOutput)
+		s += ": " + strings.Join(r.CDN.CDNIPs, ",")
+	}
+	if len(r.CDN.RealIPs) > 0 && !(len(r.CDN.RealIPs) == 1 && r.CDN.RealIPs[0] == r.Host) {
+		s += " | RealIP: " + strings.Join(r.CDN.RealIPs, ",")
+	}
+	return s
+}
+
+// fingerLabel 指纹展示格式：CMS/版本(L等级)
+func fingerLabel(f DetectionResult) string {
+	if f.Version != "" {
+		return fmt.Sprintf("%s/%s(L%d)", f.CMS, f.Version, f.Level)
 	}
+	return fmt.Sprintf("%s(L%d)", f.CMS, f.Level)
 }
@@ -0,0 +1,140 @@
+package finger
+
+import (
+	"dfinger/common"
+	"dfinger/core/network"
+	"fmt"
+	"github.com/projectdiscovery/gologger"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Probe 主动探测请求，如 GET /nacos/、GET /actuator/health
+type Probe struct {
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`   // 请求方法，默认 GET
+	Path    string            `json:"path" yaml:"path"`                           // 请求路径，可带查询参数
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // 额外请求头
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`       // 请求体
+}
+
+// key 探测请求的去重 key，相同请求只发送一次
+func (p Probe) key() string {
+	names := make([]string, 0, len(p.Headers))
+	for k := range p.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(p.Method + " " + p.Path)
+	for _, k := range names {
+		sb.WriteString("\n" + http.CanonicalHeaderKey(k) + ": " + p.Headers[k])
+	}
+	sb.WriteString("\n\n" + p.Body)
+	return sb.String()
+}
+
+// addProbe 规范化并登记探测请求，返回去重后的下标
+func (rs *RuleSet) addProbe(p *Probe) (int, error) {
+	probe := *p
+	probe.Method = strings.ToUpper(strings.TrimSpace(probe.Method))
+	if probe.Method == "" {
+		probe.Method = http.MethodGet
+	}
+	if !strings.HasPrefix(probe.Path, "/") {
+		return 0, fmt.Errorf("探测路径必须以 / 开头: %q", probe.Path)
+	}
+
+	key := probe.key()
+	if idx, ok := rs.probeIndex[key]; ok {
+		return idx, nil
+	}
+	idx := len(rs.probes)
+	rs.probes = append(rs.probes, probe)
+	rs.probeRules = append(rs.probeRules, nil)
+	rs.probeIndex[key] = idx
+	return idx, nil
+}
+
+// Probes 返回去重后的主动探测请求
+func (rs *RuleSet) Probes() []Probe {
+	if rs == nil {
+		return nil
+	}
+	return rs.probes
+}
+
+// 主动探测统计
+var (
+	probesSent    atomic.Int64
+	probesFailed  atomic.Int64
+	probesSkipped atomic.Int64
+)
+
+// ProbeSummary 返回主动探测的统计信息
+func ProbeSummary() (sent, failed, skipped int64) {
+	return probesSent.Load(), probesFailed.Load(), probesSkipped.Load()
+}
+
+// RunProbes 对单个目标发送规则中的主动探测请求，探测在编译时已按请求去重，每个只发送一次，
+// common.Infos.MaxProbes 为 0 时不探测，超出上限的探测跳过并计数。home 为首页的检测输入，favicon 和 JARM 等目标级数据沿用首页的
+func RunProbes(rules *RuleSet, baseReq *http.Request, client *http.Client, home *ResponseData) []DetectionResult {
+	probes := rules.Probes()
+	limit := common.Infos.MaxProbes
+	// 未开启主动探测时不计入跳过数
+	if len(probes) == 0 || limit <= 0 {
+		return nil
+	}
+	if limit < len(probes) {
+		probesSkipped.Add(int64(len(probes) - limit))
+		probes = probes[:limit]
+	}
+
+	var results []DetectionResult
+	for i, probe := range probes {
+		input := sendProbe(baseReq, client, probe, home)
+		if input == nil {
+			continue
+		}
+		results = append(results, rules.DetectProbe(i, input)...)
+	}
+	return results
+}
+
+// sendProbe 发送单个探测请求，失败返回 nil
+func sendProbe(baseReq *http.Request, client *http.Client, probe Probe, home *ResponseData) *ResponseData {
+	target, err := baseReq.URL.Parse(probe.Path)
+	if err != nil {
+		probesFailed.Add(1)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(baseReq.Context(), probe.Method, target.String(), strings.NewReader(probe.Body))
+	if err != nil {
+		probesFailed.Add(1)
+		return nil
+	}
+	req.Host = baseReq.Host
+	for k, v := range baseReq.Header {
+		req.Header[k] = v
+	}
+	for k, v := range probe.Headers {
+		req.Header.Set(k, v)
+	}
+
+	probesSent.Add(1)
+	// 请求体无法重复读取，不做重试
+	resp, body, err := network.DoWithRetry(client, req, 0, 0, 0)
+	if err != nil || resp == nil || resp.Body == nil {
+		probesFailed.Add(1)
+		gologger.Debug().Msgf("主动探测失败 %s %s: %v", probe.Method, target, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	input := newResponseData(resp, body, network.RawBody(resp), home.FaviconHash, target.Path)
+	input.JARM = home.JARM
+	return input
+}
@@ -0,0 +1,88 @@
+package finger
+
+import (
+	"context"
+	"fmt"
+	"github.com/projectdiscovery/gologger"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Swap 原子替换检测器使用的规则集，正在检测的目标继续使用旧规则集直到结束
+func (fd *FingerprintDetector) Swap(rules *RuleSet) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.rules = rules
+}
+
+// Reload 重新加载并编译规则，失败时保留旧规则
+func (fd *FingerprintDetector) Reload(spec string) error {
+	rules, err := LoadFingerprints(spec)
+	if err != nil {
+		return err
+	}
+	fd.Swap(rules)
+	return nil
+}
+
+// WatchRules 收到 SIGHUP 时重新加载规则；interval 大于 0 时定期检查规则文件的
+// 修改时间和大小（包括目录中新增、删除的文件），有变化时重新加载。ctx 结束后退出
+func (fd *FingerprintDetector) WatchRules(ctx context.Context, spec string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 && spec != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := ruleFilesState(spec)
+	reload := func(reason string) {
+		if err := fd.Reload(spec); err != nil {
+			gologger.Error().Msgf("指纹规则重新加载失败（%s），继续使用旧规则: %v", reason, err)
+			return
+		}
+		gologger.Info().Msgf("指纹规则已重新加载（%s）: %d 条", reason, fd.Rules().Len())
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = ruleFilesState(spec)
+			reload("SIGHUP")
+		case <-tick:
+			state := ruleFilesState(spec)
+			if state == last {
+				continue
+			}
+			last = state
+			reload("文件变化")
+		}
+	}
+}
+
+// ruleFilesState 规则文件的路径、大小和修改时间摘要
+func ruleFilesState(spec string) string {
+	paths, err := ExpandRulePaths(spec)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	var sb strings.Builder
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s|missing\n", p)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s|%d|%d\n", p, info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+package finger
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reloadRules 两条关键字相同的规则，检测结果只能来自同一版本的规则集
+func reloadRules(version string) string {
+	rule := func(name string) string {
+		return `{"cms":"` + name + `","level":3,"logic":"and","tags":[],"conditions":[{"location":"body","matcher":"match","keywords":["reload-test-marker"]}]}`
+	}
+	return `[` + rule("热加载测试"+version+"-1") + `,` + rule("热加载测试"+version+"-2") + `]`
+}
+
+func reloadInput() *ResponseData {
+	return &ResponseData{
+		Resp: &http.Response{StatusCode: 200, Header: http.Header{}},
+		Body: "<html>reload-test-marker</html>",
+		Path: "/",
+	}
+}
+
+// reloadHits 命中的热加载测试规则
+func reloadHits(fd *FingerprintDetector) []string {
+	var names []string
+	for _, r := range fd.Detect(reloadInput()) {
+		if strings.HasPrefix(r.CMS, "热加载测试") {
+			names = append(names, r.CMS)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDetectorReload(t *testing.T) {
+	dir := writeRuleFiles(t, map[string]string{"user.json": reloadRules("A")})
+	rules, err := LoadFingerprints(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := NewDetector(rules)
+	if got := reloadHits(fd); strings.Join(got, ",") != "热加载测试A-1,热加载测试A-2" {
+		t.Fatalf("初始规则命中 %q", got)
+	}
+
+	path := filepath.Join(dir, "user.json")
+	bad := map[string]string{
+		"JSON 格式错误": `[{"cms":"坏规则",`,
+		"正则错误":      `[{"cms":"坏规则","level":3,"logic":"and","tags":[],"conditions":[{"location":"body","matcher":"regex","keywords":["(unclosed"]}]}]`,
+		"未知位置":      `[{"cms":"坏规则","level":3,"logic":"and","tags":[],"conditions":[{"location":"nowhere","matcher":"match","keywords":["x"]}]}]`,
+	}
+	for name, content := range bad {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := fd.Reload(dir); err == nil {
+			t.Errorf("%s: 重新加载应失败", name)
+		}
+		if fd.Rules() != rules {
+			t.Fatalf("%s: 加载失败后规则集被替换", name)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(reloadRules("B")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fd.Reload(dir); err != nil {
+		t.Fatal(err)
+	}
+	if fd.Rules() == rules {
+		t.Fatal("规则集没有替换")
+	}
+	if got := reloadHits(fd); strings.Join(got, ",") != "热加载测试B-1,热加载测试B-2" {
+		t.Fatalf("重新加载后命中 %q", got)
+	}
+}
+
+func TestWatchRules(t *testing.T) {
+	dir := writeRuleFiles(t, map[string]string{"user.json": reloadRules("A")})
+	path := filepath.Join(dir, "user.json")
+	rules, err := LoadFingerprints(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := NewDetector(rules)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		fd.WatchRules(ctx, dir, 10*time.Millisecond)
+	}()
+
+	// 替换规则的同时持续检测，每次结果都必须完整来自某一个版本
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				got := strings.Join(reloadHits(fd), ",")
+				if got != "热加载测试A-1,热加载测试A-2" && got != "热加载测试B-1,热加载测试B-2" {
+					t.Errorf("检测结果混合了新旧规则: %q", got)
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+		<-watchDone
+	}()
+
+	// 写入错误的规则文件，检查到变化后仍使用旧规则
+	if err := os.WriteFile(path, []byte(`[{"cms":"坏规则",`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if fd.Rules() != rules {
+		t.Fatal("错误的规则文件替换了规则集")
+	}
+
+	if err := os.WriteFile(path, []byte(reloadRules("B")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for fd.Rules() == rules {
+		if time.Now().After(deadline) {
+			t.Fatal("规则文件修改后没有重新加载")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := reloadHits(fd); strings.Join(got, ",") != "热加载测试B-1,热加载测试B-2" {
+		t.Fatalf("重新加载后命中 %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package finger
+
+import (
+	"bytes"
+	"dfinger/core/network"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ScanResult 单个目标的识别结果，JSON 输出时每个目标一行，字段名保持稳定
+type ScanResult struct {
+	URL           string            `json:"input"`               // 扫描目标 scheme://host:port/path
+	FinalURL      string            `json:"final_url"`           // 跟随跳转后的最终地址
+	Host          string            `json:"host"`                // 目标主机，域名或 IP
+	IP            string            `json:"ip"`                  // 实际连接的 IP
+	Port          int               `json:"port"`                // 端口
+	Scheme        string            `json:"scheme"`              // http / https
+	StatusCode    int               `json:"status_code"`         // 状态码
+	Title         string            `json:"title"`               // 标题
+	ContentLength int               `json:"content_length"`      // 响应长度
+	ResponseTime  int64             `json:"response_time_ms"`    // 响应时间（毫秒，含重试和跳转）
+	Server        string            `json:"server"`              // Server 响应头
+	IconURL       string            `json:"icon_url"`            // favicon 地址
+	IconHash      string            `json:"icon_hash"`           // favicon hash
+	BodyHash      string            `json:"body_hash"`           // 原始响应体的 mmh3 hash
+	CDN           CDNResult         `json:"cdn"`                 // CDN 判断结果
+	Fingers       []DetectionResult `json:"fingers"`             // 识别到的指纹
+	Meta          PageMeta          `json:"meta"`                // 页面元信息
+	Login         *LoginInfo        `json:"login,omitempty"`     // 登录页面分析，非登录页面为空
+	Cert          *CertInfo         `json:"cert,omitempty"`      // HTTPS 证书
+	JARM          string            `json:"jarm,omitempty"`      // JARM 指纹
+	Time          time.Time         `json:"timestamp"`           // 完成时间
+	Endpoints     []Endpoint        `json:"endpoints,omitempty"` // 合并为资产时，提供相同内容的所有地址
+	Headers       http.Header       `json:"-"`                   // 首页响应头，供 HTML 报告展示
+	Icon          []byte            `json:"-"`                   // favicon 内容，供 HTML 报告展示缩略图
+}
+
+// CDNResult 目标的 CDN 判断结果
+type CDNResult struct {
+	IsCDN    bool     `json:"is_cdn"`
+	Provider string   `json:"provider"` // 命中 CNAME 特征时的 CDN 厂商
+	CNAMEs   []string `json:"cnames"`   // 域名的 CNAME 链
+	CDNIPs   []string `json:"cdn_ips"`
+	RealIPs  []string `json:"real_ips"`
+}
+
+// newCDNResult 从 CDNInfo 快照生成 CDN 判断结果
+func newCDNResult(info *network.CDNInfo) CDNResult {
+	result := CDNResult{CNAMEs: []string{}, CDNIPs: []string{}, RealIPs: []string{}}
+	if info == nil {
+		return result
+	}
+	isCDN, cdnIPs, realIPs := info.GetSnapshot()
+	result.IsCDN = isCDN
+	provider, cnames := info.GetProvider()
+	result.Provider = provider
+	result.CNAMEs = append(result.CNAMEs, cnames...)
+	for _, ip := range cdnIPs {
+		result.CDNIPs = append(result.CDNIPs, ip.String())
+	}
+	for _, ip := range realIPs {
+		result.RealIPs = append(result.RealIPs, ip.String())
+	}
+	return result
+}
+
+// JSON 返回结果的单行 JSON（不含换行），标题中常见的 < > & 不做转义
+func (r *ScanResult) JSON() ([]byte, error) {
+	// 列表字段为空时输出 []，不输出 null
+	out := *r
+	out.Fingers = make([]DetectionResult, len(r.Fingers))
+	for i, f := range r.Fingers {
+		if f.Tags == nil {
+			f.Tags = []string{}
+		}
+		if f.Matched == nil {
+			f.Matched = []string{}
+		}
+		out.Fingers[i] = f
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(&out); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// finalURL 跟随跳转后的最终地址，按 IP 访问域名目标时把 IP 换回域名
+func finalURL(resp *http.Response, req *http.Request) string {
+	final := resp.Request
+	if final == nil || final.URL == nil {
+		final = req
+	}
+	u := *final.URL
+	if final.Host != "" && final.Host != u.Host {
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(final.Host, port)
+		} else {
+			u.Host = final.Host
+		}
+	}
+	return u.String()
+}
@@ -67,7 +67,18 @@ func Run(input []common.UrlInfo, client *http.Client) error {
 		return nil
 	}
 
+	if common.Infos.JARM {
+		n := network.CollectJARM(input)
+		gologger.Info().Msgf("JARM 计算结束，%d 个端口支持 TLS", n)
+	}
+
 	//执行任务，入参有 1、输入的任务  2、client对象  3、扫描选项，实现扫描功能的拓展
 	RunTask(input, client)
+	// 等结果全部写完再打印统计
+	CloseSinks()
+
+	if sent, failed, skipped := ProbeSummary(); sent+skipped > 0 {
+		gologger.Info().Msgf("主动探测: 发送 %d 次，失败 %d 次，超出上限跳过 %d 次", sent, failed, skipped)
+	}
 	return nil
 }
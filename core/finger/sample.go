@@ -0,0 +1,183 @@
+package finger
+
+import (
+	"bufio"
+	"bytes"
+	"dfinger/core/network"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// RuleSamples 规则的回归测试样本，positive 必须命中，negative 不能命中
+type RuleSamples struct {
+	Positive []Sample `json:"positive,omitempty" yaml:"positive,omitempty"`
+	Negative []Sample `json:"negative,omitempty" yaml:"negative,omitempty"`
+}
+
+// Sample 一个样本响应
+type Sample struct {
+	Status      int               `json:"status,omitempty" yaml:"status,omitempty"`   // 状态码，默认 200
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // 响应头
+	Body        string            `json:"body,omitempty" yaml:"body,omitempty"`       // 响应体
+	FaviconHash string            `json:"favicon,omitempty" yaml:"favicon,omitempty"` // favicon hash
+	Path        string            `json:"path,omitempty" yaml:"path,omitempty"`       // 请求路径，默认 /
+	JS          string            `json:"js,omitempty" yaml:"js,omitempty"`           // 页面引用的 JS/CSS 内容
+}
+
+// input 把样本转换为检测输入，标题提取方式与扫描时一致
+func (s Sample) input() *ResponseData {
+	status := s.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := make(http.Header, len(s.Headers))
+	for k, v := range s.Headers {
+		header.Add(k, v)
+	}
+	path := s.Path
+	if path == "" {
+		path = "/"
+	}
+	in := sampleInput(&http.Response{StatusCode: status, Header: header}, []byte(s.Body), s.FaviconHash, path)
+	in.JS = s.JS
+	return in
+}
+
+// sampleInput 与扫描时一样按页面编码解码响应体
+func sampleInput(resp *http.Response, raw []byte, faviconHash string, path string) *ResponseData {
+	body, charset := network.DecodeBody(raw, resp.Header.Get("Content-Type"))
+	network.ResetBody(resp, raw, charset)
+	return newResponseData(resp, body, raw, faviconHash, path)
+}
+
+// ParseRawResponse 解析保存下来的原始 HTTP 响应（状态行 + 响应头 + 空行 + 响应体）
+// 响应体按原始字节返回，不处理 Content-Length 和分块编码
+func ParseRawResponse(data []byte) (*http.Response, []byte, error) {
+	head, body := data, []byte(nil)
+	if i := bytes.Index(data, []byte("\r\n\r\n")); i >= 0 {
+		head, body = data[:i], data[i+4:]
+	} else if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+		head, body = data[:i], data[i+2:]
+	}
+
+	head = append(append([]byte(nil), head...), "\r\n\r\n"...)
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(head)), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Body.Close()
+	return resp, body, nil
+}
+
+// SampleFailure 规则测试失败项
+type SampleFailure struct {
+	Source string
+	CMS    string
+	Sample string
+	Reason string
+}
+
+func (f SampleFailure) String() string {
+	return fmt.Sprintf("%s: %s: %s: %s", f.Source, f.CMS, f.Sample, f.Reason)
+}
+
+// hasCMS 检测结果中是否包含该 CMS
+func hasCMS(results []DetectionResult, cms string) bool {
+	for _, r := range results {
+		if r.CMS == cms {
+			return true
+		}
+	}
+	return false
+}
+
+// detectAll 用全部规则（首页规则和所有主动探测规则）检测一个样本
+func detectAll(rs *RuleSet, input *ResponseData) []DetectionResult {
+	results := rs.Detect(input)
+	for i := range rs.probes {
+		results = append(results, rs.DetectProbe(i, input)...)
+	}
+	return results
+}
+
+// TestRuleSamples 用规则自带的样本测试规则，主动探测规则的样本视为探测响应
+func TestRuleSamples(fd *FingerprintDetector) (tested int, failures []SampleFailure) {
+	rs := fd.Rules()
+	for i := range rs.compiled {
+		rule := rs.compiled[i].rule
+		if rule.Samples == nil {
+			continue
+		}
+		detect := fd.Detect
+		if p := rs.compiled[i].probe; p >= 0 {
+			detect = func(input *ResponseData) []DetectionResult { return rs.DetectProbe(p, input) }
+		}
+
+		for j, s := range rule.Samples.Positive {
+			tested++
+			if !hasCMS(detect(s.input()), rule.CMS) {
+				failures = append(failures, SampleFailure{Source: "rules", CMS: rule.CMS, Sample: fmt.Sprintf("positive[%d]", j), Reason: "正样本未命中"})
+			}
+		}
+		for j, s := range rule.Samples.Negative {
+			tested++
+			if hasCMS(detect(s.input()), rule.CMS) {
+				failures = append(failures, SampleFailure{Source: "rules", CMS: rule.CMS, Sample: fmt.Sprintf("negative[%d]", j), Reason: "负样本误报"})
+			}
+		}
+	}
+	return tested, failures
+}
+
+// TestSampleDir 用目录中保存的原始响应测试规则，目录结构为
+// <dir>/<CMS>/positive/* 和 <dir>/<CMS>/negative/*，每个文件一个原始 HTTP 响应
+func TestSampleDir(fd *FingerprintDetector, dir string) (tested int, failures []SampleFailure, err error) {
+	rs := fd.Rules()
+	known := make(map[string]bool)
+	for _, r := range rs.Rules {
+		known[r.CMS] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		cms := e.Name()
+		if !known[cms] {
+			failures = append(failures, SampleFailure{Source: dir, CMS: cms, Sample: cms, Reason: "没有同名规则"})
+			continue
+		}
+		for _, kind := range []string{"positive", "negative"} {
+			files, _ := filepath.Glob(filepath.Join(dir, cms, kind, "*"))
+			sort.Strings(files)
+			for _, file := range files {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return tested, failures, err
+				}
+				resp, body, err := ParseRawResponse(data)
+				if err != nil {
+					failures = append(failures, SampleFailure{Source: dir, CMS: cms, Sample: file, Reason: fmt.Sprintf("无法解析原始响应: %v", err)})
+					continue
+				}
+
+				tested++
+				hit := hasCMS(detectAll(rs, sampleInput(resp, body, "", "/")), cms)
+				if kind == "positive" && !hit {
+					failures = append(failures, SampleFailure{Source: dir, CMS: cms, Sample: file, Reason: "正样本未命中"})
+				}
+				if kind == "negative" && hit {
+					failures = append(failures, SampleFailure{Source: dir, CMS: cms, Sample: file, Reason: "负样本误报"})
+				}
+			}
+		}
+	}
+	return tested, failures, nil
+}
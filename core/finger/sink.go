@@ -0,0 +1,220 @@
+package finger
+
+import (
+	"bufio"
+	"dfinger/common"
+	"github.com/projectdiscovery/gologger"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink 结果输出，由结果管道的写协程串行调用，实现不需要加锁
+type Sink interface {
+	Write(r *ScanResult) error
+	Flush() error
+	Close() error
+}
+
+// ConsoleSink 控制台输出，带颜色
+type ConsoleSink struct{}
+
+func (ConsoleSink) Write(r *ScanResult) error {
+	gologger.Info().Msgf("%s", consoleLine(r))
+	return nil
+}
+
+func (ConsoleSink) Flush() error { return nil }
+
+func (ConsoleSink) Close() error { return nil }
+
+// lineSink 带缓冲的按行追加的结果文件
+type lineSink struct {
+	file   *os.File
+	w      *bufio.Writer
+	format func(r *ScanResult) ([]byte, error)
+}
+
+func openLineSink(path string, format func(r *ScanResult) ([]byte, error)) (*lineSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &lineSink{file: f, w: bufio.NewWriterSize(f, 64*1024), format: format}, nil
+}
+
+// NewTextSink 纯文本结果文件，追加写入
+func NewTextSink(path string) (Sink, error) {
+	return openLineSink(path, func(r *ScanResult) ([]byte, error) {
+		return []byte(textLine(r)), nil
+	})
+}
+
+// NewJSONSink JSON Lines 结果文件，每个目标一行，追加写入
+func NewJSONSink(path string) (Sink, error) {
+	return openLineSink(path, (*ScanResult).JSON)
+}
+
+func (s *lineSink) Write(r *ScanResult) error {
+	line, err := s.format(r)
+	if err != nil {
+		return err
+	}
+	s.w.Write(line)
+	return s.w.WriteByte('\n')
+}
+
+func (s *lineSink) Flush() error {
+	return s.w.Flush()
+}
+
+func (s *lineSink) Close() error {
+	if err := s.w.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// pipeline 结果管道：worker 把结果放入队列，单个写协程依次写入所有输出，并定时刷新缓冲
+type pipeline struct {
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *ScanResult
+	sinks  []Sink
+	done   chan struct{}
+	once   sync.Once
+}
+
+// 结果刷新间隔，扫描中途查看结果文件时最多落后这么久
+const flushInterval = time.Second
+
+// results 当前的结果管道，OpenSinks 之前为空，结果只输出到控制台
+var results *pipeline
+
+func newPipeline(sinks []Sink) *pipeline {
+	p := &pipeline{
+		queue: make(chan *ScanResult, 1024),
+		sinks: sinks,
+		done:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *pipeline) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case r, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			for _, s := range p.sinks {
+				if err := s.Write(r); err != nil {
+					gologger.Error().Msgf("结果写入失败: %s", err)
+				}
+			}
+		case <-ticker.C:
+			for _, s := range p.sinks {
+				if err := s.Flush(); err != nil {
+					gologger.Error().Msgf("结果写入失败: %s", err)
+				}
+			}
+		}
+	}
+}
+
+// emit 把结果放入管道，管道关闭后的结果丢弃
+func emit(r *ScanResult) {
+	p := results
+	if p == nil {
+		ConsoleSink{}.Write(r)
+		return
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+	p.queue <- r
+}
+
+// close 等待队列中的结果写完，再关闭所有输出，可以重复和并发调用
+func (p *pipeline) close() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+
+		<-p.done
+		for _, s := range p.sinks {
+			if err := s.Close(); err != nil {
+				gologger.Error().Msgf("结果写入失败: %s", err)
+			}
+		}
+	})
+}
+
+// OpenSinks 按参数打开控制台和各结果文件，并启动结果管道
+func OpenSinks() error {
+	sinks := []Sink{ConsoleSink{}}
+	fail := func(err error) error {
+		for _, s := range sinks {
+			s.Close()
+		}
+		return err
+	}
+
+	columns, err := ParseColumns(common.Infos.Columns)
+	if err != nil {
+		return err
+	}
+	mergeKey, err := ParseMergeKey(common.Infos.MergeKey)
+	if err != nil {
+		return err
+	}
+	opens := []struct {
+		path string
+		open func(path string) (Sink, error)
+	}{
+		{common.Infos.OutputFile, NewTextSink},
+		{common.Infos.JSONFile, NewJSONSink},
+		{common.Infos.CSVFile, func(path string) (Sink, error) { return NewCSVSink(path, columns) }},
+		{common.Infos.XLSXFile, func(path string) (Sink, error) { return NewXLSXSink(path, columns) }},
+		{common.Infos.HTMLFile, func(path string) (Sink, error) { return NewHTMLSink(path) }},
+	}
+	var files []Sink
+	for _, o := range opens {
+		if o.path == "" {
+			continue
+		}
+		s, err := o.open(o.path)
+		if err != nil {
+			sinks = append(sinks, files...)
+			return fail(err)
+		}
+		files = append(files, s)
+	}
+
+	// 控制台逐条输出，结果文件按资产合并后在扫描结束时输出
+	if mergeKey != nil && len(files) > 0 {
+		sinks = append(sinks, NewMergeSink(mergeKey, files))
+	} else {
+		sinks = append(sinks, files...)
+	}
+
+	results = newPipeline(sinks)
+	return nil
+}
+
+// CloseSinks 写完管道中剩余的结果并关闭所有输出，XLSX 和 HTML 在此时生成。
+// 正常结束和收到中断信号时都要调用，可以重复调用
+func CloseSinks() {
+	if p := results; p != nil {
+		p.close()
+	}
+}
@@ -0,0 +1,260 @@
+package finger
+
+import (
+	"archive/zip"
+	"bufio"
+	"dfinger/common"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// sinkFiles 测试用的各结果文件路径
+type sinkFiles struct {
+	text, json, csv, xlsx, html string
+}
+
+// openTestSinks 在临时目录中打开所有结果文件，测试结束后恢复参数和管道
+func openTestSinks(t *testing.T, merge string) sinkFiles {
+	t.Helper()
+	dir := t.TempDir()
+	files := sinkFiles{
+		text: filepath.Join(dir, "result.txt"),
+		json: filepath.Join(dir, "result.json"),
+		csv:  filepath.Join(dir, "result.csv"),
+		xlsx: filepath.Join(dir, "result.xlsx"),
+		html: filepath.Join(dir, "result.html"),
+	}
+	saved := common.Infos
+	t.Cleanup(func() {
+		CloseSinks()
+		results = nil
+		common.Infos = saved
+	})
+	common.Infos.OutputFile = files.text
+	common.Infos.JSONFile = files.json
+	common.Infos.CSVFile = files.csv
+	common.Infos.XLSXFile = files.xlsx
+	common.Infos.HTMLFile = files.html
+	common.Infos.Columns = ""
+	common.Infos.MergeKey = merge
+	if err := OpenSinks(); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func sinkResult(i int) *ScanResult {
+	return &ScanResult{
+		URL:        fmt.Sprintf("http://10.0.0.%d/", i),
+		Host:       fmt.Sprintf("10.0.0.%d", i),
+		IP:         fmt.Sprintf("10.0.0.%d", i),
+		Port:       80,
+		Scheme:     "http",
+		StatusCode: 200,
+		Title:      fmt.Sprintf("站点 %d", i),
+		Fingers:    []DetectionResult{{CMS: "nginx", Level: 3}},
+	}
+}
+
+// readLines 读取按行写入的结果文件，最后一行必须以换行结束
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if data[len(data)-1] != '\n' {
+		t.Fatalf("%s 最后一行不完整", filepath.Base(path))
+	}
+	var lines []string
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
+
+// readJSONLines 解析 JSON Lines 结果文件，每行都必须是完整的 JSON
+func readJSONLines(t *testing.T, path string) []ScanResult {
+	t.Helper()
+	var list []ScanResult
+	for i, line := range readLines(t, path) {
+		var r ScanResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("JSON 第 %d 行无法解析: %v: %s", i+1, err, line)
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+// readCSV 解析 CSV 报表，去掉 BOM 和表头
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV 无法解析: %v", err)
+	}
+	if len(rows) == 0 {
+		t.Fatal("CSV 缺少表头")
+	}
+	return rows[1:]
+}
+
+// readXLSXRows 读取 XLSX 结果工作表，返回除表头外的行数
+func readXLSXRows(t *testing.T, path string) int {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("XLSX 无法打开: %v", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		var sheet xlsxSheet
+		if err := xml.NewDecoder(rc).Decode(&sheet); err != nil {
+			t.Fatalf("XLSX 结果工作表无法解析: %v", err)
+		}
+		return len(sheet.Rows) - 1
+	}
+	t.Fatal("XLSX 缺少结果工作表")
+	return 0
+}
+
+// readHTMLReport 取出 HTML 报告中内嵌的结果数据
+func readHTMLReport(t *testing.T, path string) (string, []htmlRecord) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	const open = `<script type="application/json" id="data">`
+	_, rest, ok := strings.Cut(page, open)
+	if !ok {
+		t.Fatal("HTML 报告缺少结果数据")
+	}
+	raw, _, ok := strings.Cut(rest, "</script>")
+	if !ok || !strings.HasSuffix(strings.TrimSpace(page), "</html>") {
+		t.Fatal("HTML 报告不完整")
+	}
+	var records []htmlRecord
+	if err := json.Unmarshal([]byte(raw), &records); err != nil {
+		t.Fatalf("HTML 报告数据无法解析: %v", err)
+	}
+	return page, records
+}
+
+// checkSinkFiles 检查所有结果文件完整并且条数一致，返回条数
+func checkSinkFiles(t *testing.T, files sinkFiles) int {
+	t.Helper()
+	n := len(readLines(t, files.text))
+	jsonLines := readJSONLines(t, files.json)
+	csvRows := readCSV(t, files.csv)
+	xlsxRows := readXLSXRows(t, files.xlsx)
+	_, records := readHTMLReport(t, files.html)
+	if len(jsonLines) != n || len(csvRows) != n || xlsxRows != n || len(records) != n {
+		t.Fatalf("结果条数不一致: text %d, json %d, csv %d, xlsx %d, html %d",
+			n, len(jsonLines), len(csvRows), xlsxRows, len(records))
+	}
+	return n
+}
+
+func TestSinkPipeline(t *testing.T) {
+	files := openTestSinks(t, "")
+	const total = 3000 // 超过队列长度
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < total; i += 8 {
+				emit(sinkResult(i))
+			}
+		}(w)
+	}
+	wg.Wait()
+	CloseSinks()
+	CloseSinks()
+
+	if n := checkSinkFiles(t, files); n != total {
+		t.Fatalf("写入 %d 条结果，期望 %d", n, total)
+	}
+	seen := make(map[string]bool)
+	for _, r := range readJSONLines(t, files.json) {
+		seen[r.URL] = true
+	}
+	if len(seen) != total {
+		t.Fatalf("JSON 中有 %d 个不同目标，期望 %d", len(seen), total)
+	}
+
+	// 关闭后的结果直接丢弃
+	emit(sinkResult(total))
+	if n := len(readLines(t, files.text)); n != total {
+		t.Fatalf("关闭后仍写入了结果: %d 行", n)
+	}
+}
+
+func TestSinkPipelineInterrupt(t *testing.T) {
+	files := openTestSinks(t, "")
+	// 模拟扫描中途收到中断信号：worker 仍在输出时，信号处理和 main 的 defer 同时关闭管道
+	stop := make(chan struct{})
+	var emitted atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; ; i += 8 {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				emit(sinkResult(i))
+				emitted.Add(1)
+			}
+		}(w)
+	}
+	for emitted.Load() < 2000 {
+		runtime.Gosched()
+	}
+	var closers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			CloseSinks()
+		}()
+	}
+	closers.Wait()
+	close(stop)
+	wg.Wait()
+
+	if n := checkSinkFiles(t, files); n == 0 {
+		t.Fatal("中断前的结果没有写入")
+	}
+}
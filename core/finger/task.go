@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/logrusorgru/aurora"
 	"github.com/projectdiscovery/gologger"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -27,9 +28,23 @@ func RunTask(urls []common.UrlInfo, client *http.Client) {
 	taskChan := make(chan ScanTask, len(urls)*5) // 预估放大容量
 	numWorkers := common.Infos.Threads
 
+	// 证书域名扩展：新目标在 worker 中产生，先计数再异步入队，避免队列满时 worker 互相等待
+	var expander *targetExpander
+	if scope := common.Infos.SANScope; scope != "" {
+		expander = newTargetExpander(scope, urls)
+		expander.queue = func(tasks []ScanTask) {
+			wg.Add(len(tasks))
+			go func() {
+				for _, task := range tasks {
+					taskChan <- task
+				}
+			}()
+		}
+	}
+
 	// 启动工作协程池
 	for i := 0; i < numWorkers; i++ {
-		go worker(taskChan, client, &wg)
+		go worker(taskChan, client, &wg, expander)
 	}
 
 	tasks := GenerateScanTasks(urls)
@@ -38,11 +53,15 @@ func RunTask(urls []common.UrlInfo, client *http.Client) {
 		taskChan <- task
 	}
 
-	close(taskChan)
+	// 扩展出的任务也完成后才关闭队列
 	wg.Wait()
+	close(taskChan)
+	if expander != nil {
+		gologger.Info().Msgf("证书域名扩展: 新增目标 %d 个", expander.added.Load())
+	}
 }
 
-func worker(taskChan chan ScanTask, client *http.Client, wg *sync.WaitGroup) {
+func worker(taskChan chan ScanTask, client *http.Client, wg *sync.WaitGroup, expander *targetExpander) {
 	defer func() {
 		if r := recover(); r != nil {
 			gologger.Error().Msgf("任务崩溃: %v", r)
@@ -54,92 +73,122 @@ func worker(taskChan chan ScanTask, client *http.Client, wg *sync.WaitGroup) {
 	}
 
 	for task := range taskChan {
-		sendRequest(task.Req, client, task.UrlInfo, wg, *task.Cdninfo)
+		result := sendRequest(task.Req, client, task.UrlInfo, task.Cdninfo)
+		if result != nil && expander != nil {
+			expander.expand(result, task)
+		}
+		wg.Done()
 	}
 }
 
-// sendRequest 发送 HTTP 请求并处理响应
-func sendRequest(req *http.Request, client *http.Client, urlInfo common.UrlInfo, wg *sync.WaitGroup, cdninfo network.CDNInfo) {
-	var (
-		iconHash      string
-		title         string
-		body          string
-		contentLength int
-		fingers       []DetectionResult
-	)
-
+// sendRequest 发送 HTTP 请求并处理响应，请求失败返回 nil
+func sendRequest(req *http.Request, client *http.Client, urlInfo common.UrlInfo, cdninfo *network.CDNInfo) *ScanResult {
 	if client == nil {
 		gologger.Info().Msgf("HTTP client is nil.")
-		wg.Done()
-		return
+		return nil
 	}
 
 	if urlInfo.Host == "" || urlInfo.Scheme == "" || urlInfo.Port == "" {
 		gologger.Info().Msgf("Invalid UrlInfo: %+v", urlInfo)
-		wg.Done()
-		return
+		return nil
 	}
 
+	// JS 跳转会改写 req.URL，先记下实际连接的 IP
+	ip := req.URL.Hostname()
+	start := time.Now()
+
 	// 使用带重试的请求发送器
 	resp, body, err := network.DoWithRetry(client, req, 2, 1*time.Second, 3)
+	elapsed := time.Since(start)
 	if err != nil {
 		gologger.Debug().Msgf("请求失败: %v\n", err)
-		wg.Done()
-		return
+		return nil
 	} else {
 		gologger.Debug().Msgf("%v请求结束", req.URL.String())
 	}
 	defer resp.Body.Close()
 
 	// 分析返回数据
-	if resp != nil && resp.Body != nil {
-		title, _, iconHash, contentLength, fingers = AnalyzeResponse(resp, body, req, client, urlInfo)
-
-		PrintResult(urlInfo.Scheme+"://"+urlInfo.Host+":"+urlInfo.Port+urlInfo.Path, resp.StatusCode, title, contentLength, iconHash, fingers)
-
+	if resp == nil || resp.Body == nil {
+		return nil
 	}
-
-	wg.Done()
+	result := AnalyzeResponse(resp, body, req, client, urlInfo)
+	result.IP = ip
+	result.ResponseTime = elapsed.Milliseconds()
+	result.CDN = newCDNResult(cdninfo)
+	PrintResult(result)
+	return result
 }
 
 func GenerateScanTasks(urls []common.UrlInfo) []ScanTask {
 	var tasks []ScanTask
+	var skipped int
 	for _, urlInfo := range urls {
 		cdnInfo := network.NewCDNInfo()
 
 		if urlInfo.IsDomain {
-			if network.DefaultCDNChecker.IsCDNCNAME(urlInfo.Host) {
-				gologger.Info().Msgf(aurora.Red(fmt.Sprintf("%v 命中CDN CNAME", urlInfo.Host)).String())
-				cdnInfo.MarkAsCDN()
-			}
-
 			resolver := common.Resolver
-			ips, err := resolver.LookupIP(urlInfo.Host)
+			resolved, err := resolver.Lookup(urlInfo.Host)
 			if err != nil {
 				gologger.Info().Msgf("DNS 解析失败 (%s): %v\n", urlInfo.Host, err)
 				continue
 			}
+			ips := resolved.IPs
+
+			// 域名本身和 CNAME 链中的每一级都检查 CDN 特征
+			cdnInfo.SetCNAMEs(resolved.CNAMEs)
+			name, provider, cnameHit := network.DefaultCDNChecker.MatchCNAMEChain(append([]string{urlInfo.Host}, resolved.CNAMEs...))
+			if cnameHit {
+				gologger.Info().Msgf(aurora.Red(fmt.Sprintf("%v 命中CDN CNAME %v（%v）", urlInfo.Host, name, provider)).String())
+				cdnInfo.MarkAsCDNProvider(provider)
+			}
 
-			for _, ip := range ips {
-				url := fmt.Sprintf("%s://%s:%s%s", urlInfo.Scheme, ip.String(), urlInfo.Port, urlInfo.Path)
-				req, err := http.NewRequest("GET", url, nil)
-				if err != nil {
-					gologger.Debug().Msgf("构造请求失败: %v", err)
-					continue
-				}
-				req.Host = urlInfo.Host
-
-				if network.DefaultCDNChecker.IsCDNIP(ip) {
-					gologger.Info().Msgf(aurora.Red(fmt.Sprintf("%v 命中CDN IP段", string(ip))).String())
+			// 先把全部解析结果分为 CDN 节点和真实 IP，每个结果都带完整的 CDN 判断
+			edges := make([]bool, len(ips))
+			for i, ip := range ips {
+				// 命中 CDN CNAME 的域名，解析出的 IP 都是 CDN 节点
+				if cnameHit || network.DefaultCDNChecker.IsCDNIP(ip) {
+					if !cnameHit {
+						gologger.Info().Msgf(aurora.Red(fmt.Sprintf("%v 命中CDN IP段", ip)).String())
+					}
+					edges[i] = true
 					cdnInfo.MarkAsCDN()
 					cdnInfo.AddCDNIP(ip)
 				} else {
 					cdnInfo.AddRealIP(ip)
 				}
+			}
+
+			edgeScanned := false
+			for i, ip := range ips {
+				if edges[i] {
+					switch common.Infos.CDNMode {
+					case common.CDNSkip:
+						skipped++
+						continue
+					case common.CDNOne:
+						if edgeScanned {
+							skipped++
+							continue
+						}
+						edgeScanned = true
+					}
+				}
 
+				req, err := newDomainRequest(urlInfo, ip.String())
+				if err != nil {
+					gologger.Debug().Msgf("构造请求失败: %v", err)
+					continue
+				}
 				tasks = append(tasks, ScanTask{Req: req, UrlInfo: urlInfo, Cdninfo: cdnInfo})
 			}
 		} else {
+			cdnInfo = ipCDNInfo(urlInfo.Host)
+			if isCDN, _, _ := cdnInfo.GetSnapshot(); isCDN && common.Infos.CDNMode == common.CDNSkip {
+				skipped++
+				continue
+			}
+
 			url := fmt.Sprintf("%s://%s:%s%s", urlInfo.Scheme, urlInfo.Host, urlInfo.Port, urlInfo.Path)
 			req, err := http.NewRequest("GET", url, nil)
 			if err != nil {
@@ -149,5 +198,35 @@ func GenerateScanTasks(urls []common.UrlInfo) []ScanTask {
 			tasks = append(tasks, ScanTask{Req: req, UrlInfo: urlInfo, Cdninfo: cdnInfo})
 		}
 	}
+	if skipped > 0 {
+		gologger.Info().Msgf("CDN 节点跳过 %d 个（-cdn %s）", skipped, common.Infos.CDNMode)
+	}
 	return tasks
 }
+
+// ipCDNInfo 按 IP 段判断单个 IP 是否为 CDN 节点
+func ipCDNInfo(host string) *network.CDNInfo {
+	cdnInfo := network.NewCDNInfo()
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return cdnInfo
+	}
+	if network.DefaultCDNChecker.IsCDNIP(ip) {
+		cdnInfo.MarkAsCDN()
+		cdnInfo.AddCDNIP(ip)
+	} else {
+		cdnInfo.AddRealIP(ip)
+	}
+	return cdnInfo
+}
+
+// newDomainRequest 构造按 IP 访问域名目标的请求，Host 头和 https 的 SNI 都使用域名
+func newDomainRequest(urlInfo common.UrlInfo, ip string) (*http.Request, error) {
+	addr := net.JoinHostPort(ip, urlInfo.Port)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s://%s%s", urlInfo.Scheme, addr, urlInfo.Path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = urlInfo.Host
+	return req.WithContext(network.WithServerName(req.Context(), req.URL.Host, urlInfo.Host)), nil
+}
@@ -0,0 +1,224 @@
+package finger
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// XLSXSink XLSX 报表，第一个工作表为扫描结果，第二个为按指纹和端口的统计。
+// xlsx 需要在结束时整体写出，结果先缓存在内存中，Close 时生成文件
+type XLSXSink struct {
+	path    string
+	columns []string
+	rows    [][]string
+	cms     map[string]int
+	ports   map[int]int
+}
+
+// NewXLSXSink 创建 XLSX 报表，先检查文件可写
+func NewXLSXSink(path string, columns []string) (*XLSXSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return &XLSXSink{
+		path:    path,
+		columns: columns,
+		cms:     make(map[string]int),
+		ports:   make(map[int]int),
+	}, nil
+}
+
+func (w *XLSXSink) Write(r *ScanResult) error {
+	w.rows = append(w.rows, reportRow(w.columns, r))
+	// 同一目标命中同一 CMS 多次（首页和主动探测）只计一次
+	seen := make(map[string]bool)
+	for _, f := range r.Fingers {
+		if !seen[f.CMS] {
+			seen[f.CMS] = true
+			w.cms[f.CMS]++
+		}
+	}
+	w.ports[r.Port]++
+	return nil
+}
+
+// Flush xlsx 只在 Close 时整体写出
+func (w *XLSXSink) Flush() error {
+	return nil
+}
+
+func (w *XLSXSink) Close() error {
+	f, err := os.Create(w.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	files := []struct {
+		name  string
+		write func(io.Writer)
+	}{
+		{"[Content_Types].xml", func(out io.Writer) { io.WriteString(out, xlsxContentTypes) }},
+		{"_rels/.rels", func(out io.Writer) { io.WriteString(out, xlsxRootRels) }},
+		{"xl/workbook.xml", func(out io.Writer) { io.WriteString(out, xlsxWorkbook) }},
+		{"xl/_rels/workbook.xml.rels", func(out io.Writer) { io.WriteString(out, xlsxWorkbookRels) }},
+		{"xl/styles.xml", func(out io.Writer) { io.WriteString(out, xlsxStyles) }},
+		{"xl/worksheets/sheet1.xml", w.writeResults},
+		{"xl/worksheets/sheet2.xml", w.writeSummary},
+	}
+	for _, file := range files {
+		out, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		file.write(out)
+	}
+	return zw.Close()
+}
+
+// xlsxCell 单元格内容
+type xlsxCell struct {
+	value   string
+	numeric bool
+	bold    bool
+}
+
+// writeResults 结果工作表：表头加每个目标一行
+func (w *XLSXSink) writeResults(out io.Writer) {
+	header := make([]xlsxCell, len(w.columns))
+	for i, title := range reportHeader(w.columns) {
+		header[i] = xlsxCell{value: title, bold: true}
+	}
+	rows := [][]xlsxCell{header}
+	for _, row := range w.rows {
+		cells := make([]xlsxCell, len(row))
+		for i, v := range row {
+			cells[i] = xlsxCell{value: v, numeric: reportColumns[w.columns[i]].numeric}
+		}
+		rows = append(rows, cells)
+	}
+	writeSheet(out, rows, true)
+}
+
+// writeSummary 统计工作表：按数量从多到少列出各指纹和各端口的目标数
+func (w *XLSXSink) writeSummary(out io.Writer) {
+	rows := [][]xlsxCell{{{value: "指纹", bold: true}, {value: "目标数", bold: true}}}
+	names := make([]string, 0, len(w.cms))
+	for name := range w.cms {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if w.cms[names[i]] != w.cms[names[j]] {
+			return w.cms[names[i]] > w.cms[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		rows = append(rows, []xlsxCell{{value: name}, {value: strconv.Itoa(w.cms[name]), numeric: true}})
+	}
+
+	rows = append(rows, nil, []xlsxCell{{value: "端口", bold: true}, {value: "目标数", bold: true}})
+	ports := make([]int, 0, len(w.ports))
+	for port := range w.ports {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if w.ports[ports[i]] != w.ports[ports[j]] {
+			return w.ports[ports[i]] > w.ports[ports[j]]
+		}
+		return ports[i] < ports[j]
+	})
+	for _, port := range ports {
+		rows = append(rows, []xlsxCell{{value: strconv.Itoa(port), numeric: true}, {value: strconv.Itoa(w.ports[port]), numeric: true}})
+	}
+	writeSheet(out, rows, false)
+}
+
+// writeSheet 写出工作表 XML，字符串使用内联字符串，不需要 sharedStrings.xml
+func writeSheet(out io.Writer, rows [][]xlsxCell, filter bool) {
+	io.WriteString(out, xml.Header)
+	io.WriteString(out, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	// 冻结表头
+	io.WriteString(out, `<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	io.WriteString(out, `<sheetData>`)
+	width := 0
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		if len(row) > width {
+			width = len(row)
+		}
+		fmt.Fprintf(out, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			style := ""
+			if cell.bold {
+				style = ` s="1"`
+			}
+			if _, err := strconv.ParseFloat(cell.value, 64); cell.numeric && err == nil {
+				fmt.Fprintf(out, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell.value)
+				continue
+			}
+			fmt.Fprintf(out, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(out, []byte(xlsxText(cell.value)))
+			io.WriteString(out, `</t></is></c>`)
+		}
+		io.WriteString(out, `</row>`)
+	}
+	io.WriteString(out, `</sheetData>`)
+	if filter && len(rows) > 0 && width > 0 {
+		fmt.Fprintf(out, `<autoFilter ref="A1:%s%d"/>`, xlsxColumn(width-1), len(rows))
+	}
+	io.WriteString(out, `</worksheet>`)
+}
+
+// xlsxColumn 列号转列名，0 -> A，26 -> AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxText 去掉 XML 不允许的控制字符，并截断到单元格长度上限
+func xlsxText(s string) string {
+	const maxCell = 32767
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != utf8.RuneError && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, s)
+	if utf8.RuneCountInString(s) > maxCell {
+		s = string([]rune(s)[:maxCell])
+	}
+	return s
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="扫描结果" sheetId="1" r:id="rId1"/><sheet name="统计" sheetId="2" r:id="rId2"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/><Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+// 样式 0 为默认，1 为加粗（表头）
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
@@ -6,10 +6,12 @@ import (
 )
 
 type CDNInfo struct {
-	mu      sync.RWMutex
-	IsCDN   bool
-	CDNIPs  []net.IP
-	RealIPs []net.IP
+	mu       sync.RWMutex
+	IsCDN    bool
+	Provider string   // 命中的 CDN 厂商
+	CNAMEs   []string // 域名的 CNAME 链
+	CDNIPs   []net.IP
+	RealIPs  []net.IP
 }
 
 func NewCDNInfo() *CDNInfo {
@@ -22,6 +24,22 @@ func (c *CDNInfo) MarkAsCDN() {
 	c.IsCDN = true
 }
 
+// MarkAsCDNProvider 标记为 CDN 并记录厂商，已有厂商时保留
+func (c *CDNInfo) MarkAsCDNProvider(provider string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.IsCDN = true
+	if c.Provider == "" {
+		c.Provider = provider
+	}
+}
+
+func (c *CDNInfo) SetCNAMEs(chain []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.CNAMEs = append([]string(nil), chain...)
+}
+
 func (c *CDNInfo) AddCDNIP(ip net.IP) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -41,3 +59,10 @@ func (c *CDNInfo) GetSnapshot() (bool, []net.IP, []net.IP) {
 	realIPsCopy := append([]net.IP(nil), c.RealIPs...)
 	return c.IsCDN, cdnIPsCopy, realIPsCopy
 }
+
+// GetProvider 返回 CDN 厂商和 CNAME 链
+func (c *CDNInfo) GetProvider() (string, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Provider, append([]string(nil), c.CNAMEs...)
+}
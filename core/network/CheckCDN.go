@@ -2,7 +2,9 @@ package network
 
 import (
 	"bufio"
-	"dfinger/common"
+	"bytes"
+	"dfinger/resource"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -12,39 +14,38 @@ import (
 var DefaultCDNChecker *CDNChecker
 
 type CDNChecker struct {
-	cnameKeywords []string
+	cnameKeywords []cnameKeyword
 	cdnCIDRs      []*net.IPNet
 }
 
-// 加载 CNAME 特征数据
-func loadCnameList(filename string) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// cnameKeyword CNAME 特征及对应的 CDN 厂商
+type cnameKeyword struct {
+	keyword  string
+	provider string
+}
 
-	var result []string
-	scanner := bufio.NewScanner(file)
+// 加载 CNAME 特征数据，每行为“特征 [厂商名]”，# 开头为注释
+func loadCnameList(r io.Reader) ([]cnameKeyword, error) {
+	var result []cnameKeyword
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			result = append(result, line)
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		kw := cnameKeyword{keyword: strings.ToLower(fields[0]), provider: strings.Join(fields[1:], " ")}
+		if kw.provider == "" {
+			kw.provider = kw.keyword
 		}
+		result = append(result, kw)
 	}
 	return result, scanner.Err()
 }
 
 // 加载 CDN IP 段（CIDR）
-func loadCDNIPList(filename string) ([]*net.IPNet, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
+func loadCDNIPList(r io.Reader) ([]*net.IPNet, error) {
 	var ipNets []*net.IPNet
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
@@ -58,13 +59,26 @@ func loadCDNIPList(filename string) ([]*net.IPNet, error) {
 	return ipNets, scanner.Err()
 }
 
-// 初始化 CDN 检查器
+// 从文件初始化 CDN 检查器
 func NewCDNChecker(cnameFile, ipFile string) (*CDNChecker, error) {
-	cnames, err := loadCnameList(cnameFile)
+	cnameData, err := os.ReadFile(cnameFile)
 	if err != nil {
 		return nil, err
 	}
-	ipnets, err := loadCDNIPList(ipFile)
+	ipData, err := os.ReadFile(ipFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewCDNCheckerFromData(cnameData, ipData)
+}
+
+// 从内存数据初始化 CDN 检查器
+func NewCDNCheckerFromData(cnameData, ipData []byte) (*CDNChecker, error) {
+	cnames, err := loadCnameList(bytes.NewReader(cnameData))
+	if err != nil {
+		return nil, err
+	}
+	ipnets, err := loadCDNIPList(bytes.NewReader(ipData))
 	if err != nil {
 		return nil, err
 	}
@@ -76,13 +90,29 @@ func NewCDNChecker(cnameFile, ipFile string) (*CDNChecker, error) {
 
 // 检查某个 CNAME 是否命中 CDN 特征
 func (c *CDNChecker) IsCDNCNAME(cname string) bool {
+	_, ok := c.MatchCNAME(cname)
+	return ok
+}
+
+// MatchCNAME 检查某个 CNAME 是否命中 CDN 特征，返回 CDN 厂商
+func (c *CDNChecker) MatchCNAME(cname string) (string, bool) {
 	cname = strings.ToLower(cname)
-	for _, keyword := range c.cnameKeywords {
-		if strings.Contains(cname, keyword) {
-			return true
+	for _, kw := range c.cnameKeywords {
+		if strings.Contains(cname, kw.keyword) {
+			return kw.provider, true
 		}
 	}
-	return false
+	return "", false
+}
+
+// MatchCNAMEChain 依次检查 CNAME 链中的每个域名，返回第一个命中的域名和 CDN 厂商
+func (c *CDNChecker) MatchCNAMEChain(chain []string) (name string, provider string, ok bool) {
+	for _, name := range chain {
+		if provider, ok := c.MatchCNAME(name); ok {
+			return name, provider, true
+		}
+	}
+	return "", "", false
 }
 
 // 检查某个 IP 是否在 CDN IP 段内
@@ -100,7 +130,7 @@ func (c *CDNChecker) IsCDNIP(ip net.IP) bool {
 
 func init() {
 	var err error
-	DefaultCDNChecker, err = NewCDNChecker(common.Cdn_cname_file, common.Cdn_ip_file)
+	DefaultCDNChecker, err = NewCDNCheckerFromData(resource.CdnCname, resource.CdnIP)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,55 @@
+package network
+
+import "testing"
+
+func TestMatchCNAMEChain(t *testing.T) {
+	checker, err := NewCDNCheckerFromData([]byte("# 测试特征\ncdn.dnsv1.com 腾讯云\ncdntip.com 腾讯云 EdgeOne\nakamaiedge.net\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		chain    []string
+		wantName string
+		provider string
+		ok       bool
+	}{
+		{
+			name:     "命中中间的 CNAME",
+			chain:    []string{"www.example.com", "www.example.com.CDN.dnsv1.com", "origin.example.net"},
+			wantName: "www.example.com.CDN.dnsv1.com",
+			provider: "腾讯云",
+			ok:       true,
+		},
+		{
+			name:     "多个命中时取链中第一个",
+			chain:    []string{"www.example.com", "x.cdntip.com", "y.cdn.dnsv1.com"},
+			wantName: "x.cdntip.com",
+			provider: "腾讯云 EdgeOne",
+			ok:       true,
+		},
+		{
+			name:     "没有厂商名时使用特征",
+			chain:    []string{"www.example.com", "e1.akamaiedge.net"},
+			wantName: "e1.akamaiedge.net",
+			provider: "akamaiedge.net",
+			ok:       true,
+		},
+		{
+			name:  "不命中",
+			chain: []string{"www.example.com", "lb.example.net"},
+		},
+		{
+			name: "空链",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, provider, ok := checker.MatchCNAMEChain(tt.chain)
+			if name != tt.wantName || provider != tt.provider || ok != tt.ok {
+				t.Fatalf("MatchCNAMEChain(%v) = %q, %q, %v，期望 %q, %q, %v", tt.chain, name, provider, ok, tt.wantName, tt.provider, tt.ok)
+			}
+		})
+	}
+}
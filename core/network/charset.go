@@ -0,0 +1,169 @@
+package network
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DetectCharset 判断响应体编码，依次参考 Content-Type 的 charset 参数、BOM、<meta charset>，
+// 都没有声明时按内容推测：合法 UTF-8 视为 UTF-8，否则按 GB18030（兼容 GBK/GB2312）处理。
+// 非文本类型的响应返回空字符串
+func DetectCharset(raw []byte, contentType string) string {
+	if !isTextContent(contentType) {
+		return ""
+	}
+	_, name, certain := charset.DetermineEncoding(raw, contentType)
+	// 无任何声明时 DetermineEncoding 默认返回 windows-1252，此时改为按内容推测
+	if certain || name != "windows-1252" || declaresCharset(raw, contentType) {
+		return name
+	}
+	if utf8.Valid(raw) {
+		return "utf-8"
+	}
+	return "gb18030"
+}
+
+// DecodeBody 把响应体转换为 UTF-8，返回转换后的文本和识别出的编码
+func DecodeBody(raw []byte, contentType string) (string, string) {
+	name := DetectCharset(raw, contentType)
+	switch name {
+	case "", "utf-8":
+		return string(raw), name
+	case "gb18030":
+		// 未声明编码时使用，GB18030 是 GBK/GB2312 的超集
+		if text, err := simplifiedchinese.GB18030.NewDecoder().Bytes(raw); err == nil {
+			return string(text), name
+		}
+		return string(raw), ""
+	}
+	enc, _ := charset.Lookup(name)
+	if enc == nil {
+		return string(raw), ""
+	}
+	text, err := enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		return string(raw), ""
+	}
+	return string(text), name
+}
+
+// bufferedBody 已读取过的响应体，保留原始字节和识别出的编码，可重复读取
+type bufferedBody struct {
+	*bytes.Reader
+	raw     []byte
+	charset string
+}
+
+func (b *bufferedBody) Close() error {
+	return nil
+}
+
+// ResetBody 用原始字节重置响应体，并记录解码时识别出的编码，之后用 RawBody、BodyCharset 取回
+func ResetBody(resp *http.Response, raw []byte, charset string) {
+	resp.Body = &bufferedBody{Reader: bytes.NewReader(raw), raw: raw, charset: charset}
+}
+
+// BodyCharset 返回 ResetBody 记录的编码，响应体未经 ResetBody 重置时 ok 为 false
+func BodyCharset(resp *http.Response) (name string, ok bool) {
+	if resp == nil {
+		return "", false
+	}
+	if b, isBuffered := resp.Body.(*bufferedBody); isBuffered {
+		return b.charset, true
+	}
+	return "", false
+}
+
+// RawBody 返回响应中保留的原始字节，用于 hash 计算；未经 ResetBody 重置的响应体读取后重置
+func RawBody(resp *http.Response) []byte {
+	if resp == nil || resp.Body == nil {
+		return nil
+	}
+	if b, ok := resp.Body.(*bufferedBody); ok {
+		return b.raw
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw
+}
+
+// isTextContent 判断是否为需要解码的文本类型，未声明类型时按文本处理
+func isTextContent(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	ct := strings.ToLower(contentType)
+	for _, t := range []string{"text", "html", "xml", "json", "javascript"} {
+		if strings.Contains(ct, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresCharset 判断 Content-Type 或页面是否明确声明了 windows-1252 / iso-8859-1 等编码，
+// 页面中只认 <meta charset> 和 http-equiv="Content-Type" 的声明，其他位置出现的 charset 字样不算
+func declaresCharset(raw []byte, contentType string) bool {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && knownCharset(params["charset"]) {
+		return true
+	}
+	head := raw
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return knownCharset(metaCharset(head))
+}
+
+// metaCharset 返回 <meta> 标签中声明的编码
+func metaCharset(head []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(head))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if atom.Lookup(name) != atom.Meta || !hasAttr {
+				continue
+			}
+			var cs, httpEquiv, content string
+			for more := true; more; {
+				var key, val []byte
+				key, val, more = z.TagAttr()
+				switch strings.ToLower(string(key)) {
+				case "charset":
+					cs = string(val)
+				case "http-equiv":
+					httpEquiv = string(val)
+				case "content":
+					content = string(val)
+				}
+			}
+			if cs = strings.TrimSpace(cs); cs != "" {
+				return cs
+			}
+			if strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+				if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+					return params["charset"]
+				}
+			}
+		}
+	}
+}
+
+// knownCharset 判断编码名能否识别
+func knownCharset(label string) bool {
+	if label == "" {
+		return false
+	}
+	enc, _ := charset.Lookup(label)
+	return enc != nil
+}
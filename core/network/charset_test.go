@@ -0,0 +1,222 @@
+package network
+
+import (
+	"bytes"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func encodeString(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeBody(t *testing.T) {
+	const page = "<html><head>%s<title>统一身份认证平台</title></head><body>登录</body></html>"
+	html := func(head string) string { return strings.Replace(page, "%s", head, 1) }
+
+	tests := []struct {
+		name        string
+		raw         []byte
+		contentType string
+		charset     string
+		text        string
+	}{
+		{
+			name:        "未声明的 GBK 按 GB18030 解码",
+			raw:         encodeString(t, simplifiedchinese.GBK, html("")),
+			contentType: "text/html",
+			charset:     "gb18030",
+			text:        html(""),
+		},
+		{
+			name:        "GB18030 四字节字符",
+			raw:         encodeString(t, simplifiedchinese.GB18030, "<p>𠀀€</p>"),
+			contentType: "",
+			charset:     "gb18030",
+			text:        "<p>𠀀€</p>",
+		},
+		{
+			name:        "Content-Type 声明 GBK",
+			raw:         encodeString(t, simplifiedchinese.GBK, html("")),
+			contentType: "text/html; charset=GBK",
+			charset:     "gbk",
+			text:        html(""),
+		},
+		{
+			name:        "meta charset 声明 Big5",
+			raw:         encodeString(t, traditionalchinese.Big5, `<meta charset="big5"><title>統一身份認證</title>`),
+			contentType: "text/html",
+			charset:     "big5",
+			text:        `<meta charset="big5"><title>統一身份認證</title>`,
+		},
+		{
+			name:        "http-equiv 声明 GB2312",
+			raw:         encodeString(t, simplifiedchinese.GBK, html(`<meta http-equiv="Content-Type" content="text/html; charset=gb2312">`)),
+			contentType: "text/html",
+			charset:     "gbk",
+			text:        html(`<meta http-equiv="Content-Type" content="text/html; charset=gb2312">`),
+		},
+		{
+			name:        "meta 声明 iso-8859-1",
+			raw:         encodeString(t, charmap.Windows1252, `<meta charset="iso-8859-1"><p>café</p>`),
+			contentType: "text/html",
+			charset:     "windows-1252",
+			text:        `<meta charset="iso-8859-1"><p>café</p>`,
+		},
+		{
+			name:        "Content-Type 声明优先于 meta",
+			raw:         []byte(html(`<meta charset="gbk">`)),
+			contentType: "text/html; charset=utf-8",
+			charset:     "utf-8",
+			text:        html(`<meta charset="gbk">`),
+		},
+		{
+			name:        "UTF-8 BOM",
+			raw:         append([]byte("\xEF\xBB\xBF"), html(`<meta charset="gbk">`)...),
+			contentType: "text/html",
+			charset:     "utf-8",
+			text:        "\xEF\xBB\xBF" + html(`<meta charset="gbk">`),
+		},
+		{
+			name:        "UTF-16LE BOM",
+			raw:         encodeString(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), html("")),
+			contentType: "text/html",
+			charset:     "utf-16le",
+			text:        "\uFEFF" + html(""),
+		},
+		{
+			name:        "未声明的 UTF-8",
+			raw:         []byte(html("")),
+			contentType: "text/html",
+			charset:     "utf-8",
+			text:        html(""),
+		},
+		{
+			name:        "脚本中的 charset 字样不算声明",
+			raw:         encodeString(t, simplifiedchinese.GBK, html(`<script>var charset = "x";</script>`)),
+			contentType: "text/html",
+			charset:     "gb18030",
+			text:        html(`<script>var charset = "x";</script>`),
+		},
+		{
+			name:        "无效的 Content-Type 编码按内容推测",
+			raw:         encodeString(t, simplifiedchinese.GBK, html("")),
+			contentType: "text/html; charset=bogus",
+			charset:     "gb18030",
+			text:        html(""),
+		},
+		{
+			name:        "非文本类型不解码",
+			raw:         []byte{0x89, 'P', 'N', 'G', 0xc4, 0xe3},
+			contentType: "image/png",
+			charset:     "",
+			text:        "\x89PNG\xc4\xe3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectCharset(tt.raw, tt.contentType); got != tt.charset {
+				t.Errorf("DetectCharset = %q，期望 %q", got, tt.charset)
+			}
+			text, name := DecodeBody(tt.raw, tt.contentType)
+			if name != tt.charset || text != tt.text {
+				t.Errorf("DecodeBody = %q, %q，期望 %q, %q", text, name, tt.text, tt.charset)
+			}
+		})
+	}
+}
+
+func TestMetaCharset(t *testing.T) {
+	tests := []struct {
+		head string
+		want string
+	}{
+		{`<meta charset="utf-8">`, "utf-8"},
+		{`<META CHARSET=gbk>`, "gbk"},
+		{`<meta http-equiv="content-type" content="text/html; charset=big5">`, "big5"},
+		{`<meta HTTP-EQUIV="Content-Type" CONTENT="text/html;charset=GB2312"/>`, "GB2312"},
+		{`<meta name="description" content="charset=gbk">`, ""},
+		{`<script>document.charset = "gbk"</script>`, ""},
+		{`<!-- <meta charset="gbk"> -->`, ""},
+		{`<meta http-equiv="refresh" content="0; charset=gbk">`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := metaCharset([]byte(tt.head)); got != tt.want {
+			t.Errorf("metaCharset(%q) = %q，期望 %q", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestDeclaresCharset(t *testing.T) {
+	tests := []struct {
+		raw         string
+		contentType string
+		want        bool
+	}{
+		{"<p>x</p>", "text/html; charset=iso-8859-1", true},
+		{"<p>x</p>", "text/html; charset=bogus", false},
+		{"<p>x</p>", "text/html", false},
+		{`<meta charset="windows-1252">`, "", true},
+		{`<meta charset="bogus">`, "", false},
+		{`<p>charset</p>`, "", false},
+		// 只检查前 1024 字节
+		{strings.Repeat(" ", 1024) + `<meta charset="windows-1252">`, "", false},
+	}
+	for _, tt := range tests {
+		if got := declaresCharset([]byte(tt.raw), tt.contentType); got != tt.want {
+			t.Errorf("declaresCharset(%.40q, %q) = %v，期望 %v", tt.raw, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestRawBodyAndCharset(t *testing.T) {
+	raw := encodeString(t, simplifiedchinese.GBK, "<title>登录</title>")
+
+	// 未经 ResetBody 的响应体：读取后重置，可以重复读取
+	resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(raw))}
+	if _, ok := BodyCharset(resp); ok {
+		t.Fatal("未重置的响应体不应有编码")
+	}
+	if got := RawBody(resp); !bytes.Equal(got, raw) {
+		t.Fatalf("RawBody = %q", got)
+	}
+	if got, _ := io.ReadAll(resp.Body); !bytes.Equal(got, raw) {
+		t.Fatalf("RawBody 后响应体 = %q", got)
+	}
+
+	// readAndResetBody 解码一次，编码随响应体传递
+	resp = &http.Response{Header: http.Header{"Content-Type": {"text/html"}}, Body: io.NopCloser(bytes.NewReader(raw))}
+	body, err := readAndResetBody(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "<title>登录</title>" {
+		t.Fatalf("解码结果 %q", body)
+	}
+	if name, ok := BodyCharset(resp); !ok || name != "gb18030" {
+		t.Fatalf("BodyCharset = %q, %v", name, ok)
+	}
+	if got, _ := io.ReadAll(resp.Body); !bytes.Equal(got, raw) {
+		t.Fatalf("响应体应保留原始字节，实际 %q", got)
+	}
+	if got := RawBody(resp); !bytes.Equal(got, raw) {
+		t.Fatalf("读取后 RawBody 仍应返回完整原始字节，实际 %q", got)
+	}
+
+	if RawBody(nil) != nil || RawBody(&http.Response{}) != nil {
+		t.Fatal("空响应应返回 nil")
+	}
+}
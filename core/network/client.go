@@ -1,7 +1,6 @@
 package network
 
 import (
-	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -43,6 +42,11 @@ func NewDefaultHTTPClient() *http.Client {
 
 // NewHTTPClient 创建一个功能丰富的自定义 HTTP 客户端
 func NewHTTPClient(opts HTTPClient) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   opts.Timeout,
+		KeepAlive: 30 * time.Second,
+	}
+
 	// 自定义传输层
 	transport := &http.Transport{
 		ForceAttemptHTTP2: opts.Http2,
@@ -52,10 +56,7 @@ func NewHTTPClient(opts HTTPClient) *http.Client {
 			}
 			return nil, nil
 		},
-		DialContext: (&net.Dialer{
-			Timeout:   opts.Timeout,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+		DialContext:         dialer.DialContext,
 		TLSHandshakeTimeout: opts.Timeout,
 		MaxIdleConns:        opts.MaxIdleConns,
 		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
@@ -70,7 +71,7 @@ func NewHTTPClient(opts HTTPClient) *http.Client {
 
 	client := &http.Client{
 		Timeout:   opts.Timeout,
-		Transport: transport,
+		Transport: newSNITransport(transport, dialer),
 	}
 
 	// 配置是否跟随重定向
@@ -108,16 +109,16 @@ func readAndResetBody(resp *http.Response) (string, error) {
 		return "", fmt.Errorf("failed to read response body: %v", err)
 	}
 
-	// 将响应体内容转换为字符串
-	body := string(bodyBytes)
+	// 按页面编码转换为 UTF-8 字符串，GBK/Big5 等页面的标题和中文关键字才能正常匹配
+	body, charset := DecodeBody(bodyBytes, resp.Header.Get("Content-Type"))
 
-	// 恢复响应体，使其可以再次读取
-	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	// 恢复响应体，使其可以再次读取，保留原始字节供 hash 计算，编码随响应体一起传递，不再重复识别
+	ResetBody(resp, bodyBytes, charset)
 
 	return body, nil
 }
 
-// DoWithRetry 执行带重试逻辑的 HTTP 请求
+// DoWithRetry 执行带重试逻辑的 HTTP 请求，返回的 body 已转换为 UTF-8，resp.Body 中保留原始字节
 // 此处的尝试次数retryCount int尚未完成用户参数可控
 func DoWithRetry(client *http.Client, req *http.Request, retryCount int, retryDelay time.Duration, JsRedirect int) (*http.Response, string, error) {
 	var resp *http.Response
@@ -0,0 +1,468 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dfinger/common"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JARM TLS 服务端指纹：发送 10 个特制的 ClientHello，根据服务端选择的加密套件、版本、
+// ALPN 和扩展顺序生成 62 位指纹，算法与 salesforce/jarm 一致
+
+// jarmProbe 一个 ClientHello 的构造参数
+type jarmProbe struct {
+	version     string // TLS_1.1 TLS_1.2 TLS_1.3
+	ciphers     string // ALL 或 NO1.3
+	cipherOrder string // FORWARD REVERSE TOP_HALF BOTTOM_HALF MIDDLE_OUT
+	grease      bool
+	rareALPN    bool
+	support     string // 1.2_SUPPORT 1.3_SUPPORT NO_SUPPORT
+	extOrder    string // ALPN 和 supported_versions 的顺序
+}
+
+var jarmProbes = []jarmProbe{
+	{"TLS_1.2", "ALL", "FORWARD", false, false, "1.2_SUPPORT", "REVERSE"},
+	{"TLS_1.2", "ALL", "REVERSE", false, false, "1.2_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "TOP_HALF", false, false, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "BOTTOM_HALF", false, true, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.2", "ALL", "MIDDLE_OUT", true, true, "NO_SUPPORT", "REVERSE"},
+	{"TLS_1.1", "ALL", "FORWARD", false, false, "NO_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "ALL", "FORWARD", false, false, "1.3_SUPPORT", "REVERSE"},
+	{"TLS_1.3", "ALL", "REVERSE", false, false, "1.3_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "NO1.3", "FORWARD", false, false, "1.3_SUPPORT", "FORWARD"},
+	{"TLS_1.3", "ALL", "MIDDLE_OUT", true, false, "1.3_SUPPORT", "REVERSE"},
+}
+
+// ClientHello 中的加密套件，按 JARM 规定的顺序
+var jarmCiphers = []uint16{
+	0x0016, 0x0033, 0x0067, 0xc09e, 0xc0a2, 0x009e, 0x0039, 0x006b, 0xc09f, 0xc0a3, 0x009f, 0x0045, 0x00be, 0x0088,
+	0x00c4, 0x009a, 0xc008, 0xc009, 0xc023, 0xc0ac, 0xc0ae, 0xc02b, 0xc00a, 0xc024, 0xc0ad, 0xc0af, 0xc02c, 0xc072,
+	0xc073, 0xcca9, 0x1302, 0x1301, 0xcc14, 0xc007, 0xc012, 0xc013, 0xc027, 0xc02f, 0xc014, 0xc028, 0xc030, 0xc060,
+	0xc061, 0xc076, 0xc077, 0xcca8, 0x1305, 0x1304, 0x1303, 0xcc13, 0xc011, 0x000a, 0x002f, 0x003c, 0xc09c, 0xc0a0,
+	0x009c, 0x0035, 0x003d, 0xc09d, 0xc0a1, 0x009d, 0x0041, 0x00ba, 0x0084, 0x00c0, 0x0007, 0x0004, 0x0005,
+}
+
+// 计算指纹时加密套件的编号顺序
+var jarmCipherIndex = []uint16{
+	0x0004, 0x0005, 0x0007, 0x000a, 0x0016, 0x002f, 0x0033, 0x0035, 0x0039, 0x003c, 0x003d, 0x0041, 0x0045, 0x0067,
+	0x006b, 0x0084, 0x0088, 0x009a, 0x009c, 0x009d, 0x009e, 0x009f, 0x00ba, 0x00be, 0x00c0, 0x00c4, 0xc007, 0xc008,
+	0xc009, 0xc00a, 0xc011, 0xc012, 0xc013, 0xc014, 0xc023, 0xc024, 0xc027, 0xc028, 0xc02b, 0xc02c, 0xc02f, 0xc030,
+	0xc060, 0xc061, 0xc072, 0xc073, 0xc076, 0xc077, 0xc09c, 0xc09d, 0xc09e, 0xc09f, 0xc0a0, 0xc0a1, 0xc0a2, 0xc0a3,
+	0xc0ac, 0xc0ad, 0xc0ae, 0xc0af, 0xcc13, 0xcc14, 0xcca8, 0xcca9, 0x1301, 0x1302, 0x1303, 0x1304, 0x1305,
+}
+
+var (
+	jarmALPN     = []string{"http/0.9", "http/1.0", "http/1.1", "spdy/1", "spdy/2", "spdy/3", "h2", "h2c", "hq"}
+	jarmRareALPN = []string{"http/0.9", "http/1.0", "spdy/1", "spdy/2", "spdy/3", "h2c", "hq"}
+)
+
+// JARMEmpty 目标不支持 TLS 时的指纹
+var JARMEmpty = strings.Repeat("0", 62)
+
+// JARM 计算目标的 JARM 指纹，serverName 为 SNI。与原实现一致，任一探测超时时返回全 0，
+// 连接失败或被拒绝的探测记为空响应
+func JARM(address string, serverName string, timeout time.Duration) string {
+	answers := make([]string, 0, len(jarmProbes))
+	for _, p := range jarmProbes {
+		data, timedOut := sendJARMProbe(address, buildClientHello(p, serverName), timeout)
+		if timedOut {
+			return JARMEmpty
+		}
+		answers = append(answers, parseServerHello(data))
+	}
+	return jarmHash(answers)
+}
+
+// sendJARMProbe 发送一个 ClientHello 并读取服务端的第一个 TLS 记录（最多 1484 字节）
+func sendJARMProbe(address string, hello []byte, timeout time.Duration) ([]byte, bool) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, isTimeout(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(hello); err != nil {
+		return nil, isTimeout(err)
+	}
+
+	buf := make([]byte, 1484)
+	n := 0
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if n >= 5 && n >= 5+int(binary.BigEndian.Uint16(buf[3:5])) {
+			break
+		}
+		if err != nil {
+			if n == 0 {
+				return nil, isTimeout(err)
+			}
+			break
+		}
+	}
+	return buf[:n], false
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+// buildClientHello 按探测参数构造 ClientHello 记录
+func buildClientHello(p jarmProbe, serverName string) []byte {
+	var recordVersion, helloVersion uint16
+	switch p.version {
+	case "TLS_1.3":
+		recordVersion, helloVersion = 0x0301, 0x0303
+	case "TLS_1.1":
+		recordVersion, helloVersion = 0x0302, 0x0302
+	default:
+		recordVersion, helloVersion = 0x0303, 0x0303
+	}
+
+	var hello []byte
+	hello = binary.BigEndian.AppendUint16(hello, helloVersion)
+	hello = append(hello, randomBytes(32)...)
+	hello = append(hello, 32)
+	hello = append(hello, randomBytes(32)...) // session id
+
+	ciphers := jarmCiphers
+	if p.ciphers == "NO1.3" {
+		ciphers = nil
+		for _, c := range jarmCiphers {
+			if c>>8 != 0x13 {
+				ciphers = append(ciphers, c)
+			}
+		}
+	}
+	ciphers = mung(ciphers, p.cipherOrder)
+	if p.grease {
+		ciphers = append([]uint16{greaseValue()}, ciphers...)
+	}
+	hello = binary.BigEndian.AppendUint16(hello, uint16(2*len(ciphers)))
+	for _, c := range ciphers {
+		hello = binary.BigEndian.AppendUint16(hello, c)
+	}
+	hello = append(hello, 0x01, 0x00) // 压缩方法：null
+	hello = append(hello, jarmExtensions(p, serverName)...)
+
+	handshake := []byte{0x01, 0x00}
+	handshake = binary.BigEndian.AppendUint16(handshake, uint16(len(hello)))
+	handshake = append(handshake, hello...)
+
+	record := []byte{0x16}
+	record = binary.BigEndian.AppendUint16(record, recordVersion)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+// jarmExtensions 构造 ClientHello 的扩展部分（含总长度）
+func jarmExtensions(p jarmProbe, serverName string) []byte {
+	var ext []byte
+	if p.grease {
+		ext = binary.BigEndian.AppendUint16(ext, greaseValue())
+		ext = append(ext, 0x00, 0x00)
+	}
+
+	// server_name
+	ext = append(ext, 0x00, 0x00)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(serverName)+5))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(serverName)+3))
+	ext = append(ext, 0x00)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(serverName)))
+	ext = append(ext, serverName...)
+
+	ext = append(ext, 0x00, 0x17, 0x00, 0x00)                                                             // extended_master_secret
+	ext = append(ext, 0x00, 0x01, 0x00, 0x01, 0x01)                                                       // max_fragment_length
+	ext = append(ext, 0xff, 0x01, 0x00, 0x01, 0x00)                                                       // renegotiation_info
+	ext = append(ext, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x08, 0x00, 0x1d, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19) // supported_groups
+	ext = append(ext, 0x00, 0x0b, 0x00, 0x02, 0x01, 0x00)                                                 // ec_point_formats
+	ext = append(ext, 0x00, 0x23, 0x00, 0x00)                                                             // session_ticket
+
+	// application_layer_protocol_negotiation
+	alpns := jarmALPN
+	if p.rareALPN {
+		alpns = jarmRareALPN
+	}
+	var alpn []byte
+	for _, a := range mung(alpns, p.extOrder) {
+		alpn = append(alpn, byte(len(a)))
+		alpn = append(alpn, a...)
+	}
+	ext = append(ext, 0x00, 0x10)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(alpn)+2))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(alpn)))
+	ext = append(ext, alpn...)
+
+	// signature_algorithms
+	ext = append(ext, 0x00, 0x0d, 0x00, 0x14, 0x00, 0x12, 0x04, 0x03, 0x08, 0x04, 0x04, 0x01, 0x05, 0x03,
+		0x08, 0x05, 0x05, 0x01, 0x08, 0x06, 0x06, 0x01, 0x02, 0x01)
+
+	// key_share
+	var share []byte
+	if p.grease {
+		share = binary.BigEndian.AppendUint16(share, greaseValue())
+		share = append(share, 0x00, 0x01, 0x00)
+	}
+	share = append(share, 0x00, 0x1d, 0x00, 0x20)
+	share = append(share, randomBytes(32)...)
+	ext = append(ext, 0x00, 0x33)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(share)+2))
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(share)))
+	ext = append(ext, share...)
+
+	ext = append(ext, 0x00, 0x2d, 0x00, 0x02, 0x01, 0x01) // psk_key_exchange_modes
+
+	// supported_versions
+	if p.version == "TLS_1.3" || p.support == "1.2_SUPPORT" {
+		versions := []uint16{0x0301, 0x0302, 0x0303, 0x0304}
+		if p.support == "1.2_SUPPORT" {
+			versions = versions[:3]
+		}
+		var list []byte
+		if p.grease {
+			list = binary.BigEndian.AppendUint16(list, greaseValue())
+		}
+		for _, v := range mung(versions, p.extOrder) {
+			list = binary.BigEndian.AppendUint16(list, v)
+		}
+		ext = append(ext, 0x00, 0x2b)
+		ext = binary.BigEndian.AppendUint16(ext, uint16(len(list)+1))
+		ext = append(ext, byte(len(list)))
+		ext = append(ext, list...)
+	}
+
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(ext))), ext...)
+}
+
+// mung 按 JARM 规定调整列表顺序
+func mung[T any](list []T, order string) []T {
+	n := len(list)
+	var out []T
+	switch order {
+	case "REVERSE":
+		for i := n - 1; i >= 0; i-- {
+			out = append(out, list[i])
+		}
+	case "BOTTOM_HALF":
+		out = append(out, list[n/2+n%2:]...)
+	case "TOP_HALF":
+		// 前一半倒序，奇数个时中间的元素放在最前
+		if n%2 == 1 {
+			out = append(out, list[n/2])
+		}
+		out = append(out, mung(mung(list, "REVERSE"), "BOTTOM_HALF")...)
+	case "MIDDLE_OUT":
+		// 从中间向两侧交替取，后半部分在前
+		mid := n / 2
+		if n%2 == 1 {
+			out = append(out, list[mid])
+			for i := 1; i <= mid; i++ {
+				out = append(out, list[mid+i], list[mid-i])
+			}
+		} else {
+			for i := 1; i <= mid; i++ {
+				out = append(out, list[mid-1+i], list[mid-i])
+			}
+		}
+	default:
+		out = append(out, list...)
+	}
+	return out
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// greaseValue 随机选择一个 GREASE 值（0x0a0a、0x1a1a ... 0xfafa）
+func greaseValue() uint16 {
+	b := randomBytes(1)[0] >> 4
+	v := uint16(b)<<4 | 0x0a
+	return v<<8 | v
+}
+
+// parseServerHello 解析 ServerHello，返回 套件|版本|ALPN|扩展类型列表，非 ServerHello 返回 |||
+func parseServerHello(data []byte) string {
+	if len(data) < 44 || data[0] != 0x16 || data[5] != 0x02 {
+		return "|||"
+	}
+	helloLen := int(binary.BigEndian.Uint16(data[3:5]))
+	sidLen := int(data[43])
+	if len(data) < sidLen+46 {
+		return "|||"
+	}
+	cipher := hex.EncodeToString(data[sidLen+44 : sidLen+46])
+	version := hex.EncodeToString(data[9:11])
+	return cipher + "|" + version + "|" + serverHelloExtensions(data, sidLen, helloLen)
+}
+
+// serverHelloExtensions 返回 ALPN|扩展类型列表，越界时返回 |
+func serverHelloExtensions(data []byte, sidLen int, helloLen int) string {
+	at := func(i int) (byte, bool) {
+		if i < 0 || i >= len(data) {
+			return 0, false
+		}
+		return data[i], true
+	}
+	b, ok := at(sidLen + 47)
+	if !ok || b == 11 {
+		return "|"
+	}
+	if hasBytes(data, sidLen+50, []byte{0x0e, 0xac, 0x0b}) || hasBytes(data, 82, []byte{0x0f, 0xf0, 0x0b}) {
+		return "|"
+	}
+	if sidLen+42 >= helloLen {
+		return "|"
+	}
+
+	count := sidLen + 49
+	if count > len(data) {
+		return "|"
+	}
+	length := int(binary.BigEndian.Uint16(data[sidLen+47 : sidLen+49]))
+	maximum := length + count - 1
+
+	var types []string
+	var values [][]byte
+	for count < maximum {
+		if count+4 > len(data) {
+			return "|"
+		}
+		types = append(types, hex.EncodeToString(data[count:count+2]))
+		extLen := int(binary.BigEndian.Uint16(data[count+2 : count+4]))
+		end := count + 4 + extLen
+		if end > len(data) {
+			end = len(data)
+		}
+		values = append(values, data[count+4:end])
+		count += extLen + 4
+	}
+
+	var alpn string
+	for i, t := range types {
+		if t == "0010" {
+			if len(values[i]) > 3 {
+				alpn = string(values[i][3:])
+			}
+			break
+		}
+	}
+	return alpn + "|" + strings.Join(types, "-")
+}
+
+func hasBytes(data []byte, offset int, want []byte) bool {
+	return offset+len(want) <= len(data) && string(data[offset:offset+len(want)]) == string(want)
+}
+
+// jarmHash 把 10 个响应摘要转换为 62 位指纹：每个响应的套件编号（2 位）和版本（1 位），
+// 加上所有 ALPN 与扩展列表的 sha256 前 32 位
+func jarmHash(answers []string) string {
+	empty := true
+	for _, a := range answers {
+		if a != "|||" {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return JARMEmpty
+	}
+
+	var fuzzy strings.Builder
+	var alpnExt strings.Builder
+	for _, a := range answers {
+		parts := strings.SplitN(a, "|", 4)
+		for len(parts) < 4 {
+			parts = append(parts, "")
+		}
+		fuzzy.WriteString(cipherByte(parts[0]))
+		fuzzy.WriteString(versionByte(parts[1]))
+		alpnExt.WriteString(parts[2])
+		alpnExt.WriteString(parts[3])
+	}
+	sum := sha256.Sum256([]byte(alpnExt.String()))
+	return fuzzy.String() + hex.EncodeToString(sum[:])[:32]
+}
+
+func cipherByte(cipher string) string {
+	if cipher == "" {
+		return "00"
+	}
+	count := 1
+	for _, c := range jarmCipherIndex {
+		if fmt.Sprintf("%04x", c) == cipher {
+			break
+		}
+		count++
+	}
+	return fmt.Sprintf("%02x", count)
+}
+
+func versionByte(version string) string {
+	if len(version) < 4 || version[3] < '0' || version[3] > '5' {
+		return "0"
+	}
+	return string("abcdef"[version[3]-'0'])
+}
+
+// JARM 结果，key 为 主机:端口
+var jarmResults sync.Map
+
+// CollectJARM 并发计算存活目标中 https 端口的 JARM，相同 主机:端口 只计算一次，
+// 结果通过 LookupJARM 查询，不支持 TLS 的端口不记录
+func CollectJARM(alive []common.UrlInfo) int {
+	var wg sync.WaitGroup
+	var seen sync.Map
+	taskChan := make(chan common.UrlInfo, common.Infos.Threads)
+	timeout := time.Duration(common.Infos.Timeout) * time.Second
+
+	for i := 0; i < common.Infos.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskChan {
+				address := net.JoinHostPort(task.Host, task.Port)
+				if _, loaded := seen.LoadOrStore(address, true); loaded {
+					continue
+				}
+				if jarm := JARM(address, task.Host, timeout); jarm != JARMEmpty {
+					jarmResults.Store(address, jarm)
+				}
+			}
+		}()
+	}
+
+	for _, urlInfo := range alive {
+		if urlInfo.Scheme == "https" {
+			taskChan <- urlInfo
+		}
+	}
+	close(taskChan)
+	wg.Wait()
+
+	n := 0
+	jarmResults.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// LookupJARM 返回目标的 JARM，未计算或不支持 TLS 时返回空字符串
+func LookupJARM(host string, port string) string {
+	if v, ok := jarmResults.Load(net.JoinHostPort(host, port)); ok {
+		return v.(string)
+	}
+	return ""
+}
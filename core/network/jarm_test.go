@@ -0,0 +1,139 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var jarmFormat = regexp.MustCompile(`^[0-9a-f]{62}$`)
+
+// testCertificate 生成本地 TLS 服务使用的自签名证书
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startTLSServer 启动一个只做握手的 TLS 服务，返回监听地址
+func startTLSServer(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	cfg.Certificates = []tls.Certificate{testCertificate(t)}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestJARMTLSConfigs(t *testing.T) {
+	configs := map[string]*tls.Config{
+		"default": {},
+		"tls12":   {MaxVersion: tls.VersionTLS12},
+		"tls12-cbc": {
+			MaxVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA, tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA},
+		},
+		"tls13-h2": {MinVersion: tls.VersionTLS13, NextProtos: []string{"h2", "http/1.1"}},
+	}
+
+	seen := make(map[string]string)
+	for name, cfg := range configs {
+		addr := startTLSServer(t, cfg)
+		jarm := JARM(addr, "localhost", 2*time.Second)
+		if !jarmFormat.MatchString(jarm) || jarm == JARMEmpty {
+			t.Fatalf("%s: 无效的 JARM %q", name, jarm)
+		}
+		// ClientHello 中的随机数和 GREASE 不影响结果
+		if again := JARM(addr, "localhost", 2*time.Second); again != jarm {
+			t.Errorf("%s: 两次结果不同 %s %s", name, jarm, again)
+		}
+		if other, ok := seen[jarm]; ok {
+			t.Errorf("%s 与 %s 的 JARM 相同: %s", name, other, jarm)
+		}
+		seen[jarm] = name
+		t.Logf("%s: %s", name, jarm)
+	}
+}
+
+func TestJARMNotTLS(t *testing.T) {
+	// 收到数据后直接断开的非 TLS 服务
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+	if jarm := JARM(ln.Addr().String(), "localhost", time.Second); jarm != JARMEmpty {
+		t.Errorf("非 TLS 服务: %s", jarm)
+	}
+
+	// 已关闭的端口
+	addr := ln.Addr().String()
+	ln.Close()
+	if jarm := JARM(addr, "localhost", time.Second); jarm != JARMEmpty {
+		t.Errorf("关闭的端口: %s", jarm)
+	}
+}
+
+func TestJARMHash(t *testing.T) {
+	answers := []string{
+		"c02f|0303|h2|ff01-0000-0001-0010", "|||", "1301|0303||002b-0033", "c030|0302|http/1.1|0000",
+		"|||", "|||", "|||", "|||", "|||", "|||",
+	}
+	if got, want := jarmHash(answers), "29d00041d2ac0000000000000000004b7d987bddc862a8edb4cedca4215223"; got != want {
+		t.Errorf("jarmHash = %s, want %s", got, want)
+	}
+	empty := make([]string, 10)
+	for i := range empty {
+		empty[i] = "|||"
+	}
+	if got := jarmHash(empty); got != JARMEmpty {
+		t.Errorf("全部无响应时应为全 0: %s", got)
+	}
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// 按 IP 访问域名目标时，请求地址是 IP，Go 默认不会发送 SNI，拿到的是服务器的默认证书。
+// 通过 WithServerName 在请求上下文中指定连接某个地址时使用的 SNI
+
+type serverNameKey struct{}
+
+type serverNameOverride struct {
+	addr string // 目标地址 IP:端口，跳转到其他地址时不再使用该 SNI
+	name string
+}
+
+// WithServerName 返回连接 addr（IP:端口）时使用 name 作为 SNI 的上下文
+func WithServerName(ctx context.Context, addr string, name string) context.Context {
+	return context.WithValue(ctx, serverNameKey{}, serverNameOverride{addr: addr, name: name})
+}
+
+// serverNameFor 返回上下文中为 addr 指定的 SNI
+func serverNameFor(ctx context.Context, addr string) string {
+	if o, ok := ctx.Value(serverNameKey{}).(serverNameOverride); ok && o.addr == addr {
+		return o.name
+	}
+	return ""
+}
+
+// sniTransport 指定了 SNI 的 https 请求使用不复用连接的传输层，
+// 避免连接池把同一 IP 上其他域名（或不带 SNI）的连接拿来复用，其余请求走共享连接池。
+// 使用代理时 TLS 由代理连接建立，无法指定 SNI
+type sniTransport struct {
+	pooled *http.Transport
+	sni    *http.Transport
+}
+
+func newSNITransport(pooled *http.Transport, dialer *net.Dialer) *sniTransport {
+	sni := pooled.Clone()
+	sni.DisableKeepAlives = true
+	sni.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := pooled.TLSClientConfig.Clone()
+		cfg.ServerName = serverNameFor(ctx, addr)
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tc := tls.Client(conn, cfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tc, nil
+	}
+	return &sniTransport{pooled: pooled, sni: sni}
+}
+
+func (t *sniTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" && serverNameFor(req.Context(), req.URL.Host) != "" {
+		return t.sni.RoundTrip(req)
+	}
+	return t.pooled.RoundTrip(req)
+}
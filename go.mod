@@ -2,7 +2,6 @@ module dfinger
 
 go 1.23.0
 
-
 require (
 	github.com/logrusorgru/aurora v2.0.3+incompatible
 	github.com/malfunkt/iprange v0.9.0
@@ -10,6 +9,9 @@ require (
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/projectdiscovery/gologger v1.1.54
 	github.com/spaolacci/murmur3 v1.1.0
+	golang.org/x/net v0.39.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -38,10 +40,8 @@ require (
 	github.com/ulikunitz/xz v0.5.12 // indirect
 	go4.org v0.0.0-20230225012048-214862532bf5 // indirect
 	golang.org/x/mod v0.24.0 // indirect
-	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/sync v0.13.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
 	golang.org/x/tools v0.32.0 // indirect
 	gopkg.in/djherbis/times.v1 v1.3.0 // indirect
 )
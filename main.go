@@ -1,19 +1,56 @@
 package main
 
 import (
+	"context"
 	"dfinger/common"
 	"dfinger/core/finger"
 	"dfinger/core/network"
+	"github.com/projectdiscovery/gologger"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(finger.RulesCommand(os.Args[2:]))
+	}
+
 	common.Dfinger_init()
-	var err error
 	file := common.Infos.FingerFile
-	finger.Rules, err = finger.LoadFingerprints(file)
+	rules, err := finger.LoadFingerprints(file)
 	if err != nil {
 		panic(err)
 	}
+	finger.Detector = finger.NewDetector(rules)
+	gologger.Info().Msgf("加载指纹规则 %d 条", rules.Len())
+	if n := len(rules.Probes()); n > 0 && common.Infos.MaxProbes <= 0 {
+		gologger.Info().Msgf("规则中共有 %d 个主动探测请求，未开启主动探测（-probes）", n)
+	} else if n > common.Infos.MaxProbes {
+		gologger.Info().Msgf("规则中共有 %d 个主动探测请求，每个目标只发送前 %d 个（-probes）", n, common.Infos.MaxProbes)
+	}
+
+	// 长时间扫描期间监听指纹文件变化和 SIGHUP，热加载规则
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go finger.Detector.WatchRules(ctx, file, time.Duration(common.Infos.Reload)*time.Second)
+
+	if err := finger.OpenSinks(); err != nil {
+		gologger.Fatal().Msgf("无法创建结果文件: %s", err)
+	}
+	defer finger.CloseSinks()
+
+	// Ctrl-C 中断时先写完已有结果再退出，XLSX 和 HTML 报告包含中断前的结果
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		gologger.Info().Msgf("收到中断信号，正在保存结果")
+		finger.CloseSinks()
+		os.Exit(130)
+	}()
+
 	//覆写
 	common.ParseInfo.UrlInfos = finger.GenerateWebscanTasks(common.ParseInfo.Iplist, common.ParseInfo.Portlist)
 
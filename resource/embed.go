@@ -0,0 +1,19 @@
+// Package resource 内置的指纹库与 CDN 特征库，编译进二进制，不依赖运行目录
+package resource
+
+import _ "embed"
+
+// Fingers 内置指纹库
+//
+//go:embed fingers.json
+var Fingers []byte
+
+// CdnCname CDN CNAME 特征
+//
+//go:embed cdn_cname.txt
+var CdnCname []byte
+
+// CdnIP CDN IP 段
+//
+//go:embed cdn_ip.txt
+var CdnIP []byte